@@ -18,7 +18,7 @@
           dirmd = pkgs.buildGoModule {
             inherit pname version;
             src = self;
-            vendorHash = "sha256-lVUSnffsoyE1Rn8Le66CeynR/lID0hDmodE6JWt/Nao=";
+            vendorHash = "sha256-MjgCaH9eYiA4OkshRUjjBZ0dppZtoYdLNPoC4jerhYg=";
             subPackages = [ "." ];
           };
 
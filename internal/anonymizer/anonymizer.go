@@ -0,0 +1,230 @@
+// Package anonymizer replaces sensitive names with placeholders and restores them.
+package anonymizer
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"regexp"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/axseem/dirmd/internal/atomicfile"
+)
+
+// placeholderPrefix starts every placeholder, followed by a number.
+const placeholderPrefix = "anon"
+
+// placeholderLike matches text shaped like a placeholder in any case.
+var placeholderLike = regexp.MustCompile(`(?i)` + placeholderPrefix + `\d+`)
+
+// findPlaceholders returns the locations of the placeholder-like text in
+// text that is not part of a longer word, as in canon1, or number, as in
+// anon12 for anon1.
+func findPlaceholders(text string) [][]int {
+	return slices.DeleteFunc(placeholderLike.FindAllStringIndex(text, -1), func(loc []int) bool {
+		r, _ := utf8.DecodeLastRuneInString(text[:loc[0]])
+		return unicode.IsLetter(r) || unicode.IsDigit(r)
+	})
+}
+
+// rule matches one kind of sensitive text.
+type rule struct {
+	pattern *regexp.Regexp
+	// literal rules match a term in any case and share one placeholder
+	// number across all case variants.
+	literal bool
+}
+
+// Anonymizer replaces the matches of its rules with placeholders that are
+// consistent across every text it is given.
+type Anonymizer struct {
+	rules []rule
+	// mapping maps placeholders to the text they replaced.
+	mapping Mapping
+	// ids maps the key of each replaced text to its placeholder number.
+	ids  map[string]int
+	next int
+}
+
+// New creates an Anonymizer from the rules file at rulesPath. Each line of
+// the file is a term matched in any case, or, if it starts with "re:", a
+// regular expression. Blank lines and lines starting with # are ignored.
+// Placeholders already recorded in mapping are reused.
+func New(rulesPath string, mapping Mapping) (*Anonymizer, error) {
+	file, err := os.Open(rulesPath)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	a := &Anonymizer{mapping: make(Mapping), ids: make(map[string]int), next: 1}
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		if expr, ok := strings.CutPrefix(line, "re:"); ok {
+			re, err := regexp.Compile(expr)
+			if err != nil {
+				return nil, fmt.Errorf("invalid anonymization pattern %q: %w", expr, err)
+			}
+			a.rules = append(a.rules, rule{pattern: re})
+			continue
+		}
+		a.rules = append(a.rules, rule{pattern: regexp.MustCompile(`(?i)` + regexp.QuoteMeta(line)), literal: true})
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	for placeholder, original := range mapping {
+		if loc := placeholderLike.FindStringIndex(placeholder); loc == nil || loc[0] != 0 || loc[1] != len(placeholder) {
+			return nil, fmt.Errorf("invalid placeholder %q in mapping", placeholder)
+		}
+		n, err := strconv.Atoi(placeholder[len(placeholderPrefix):])
+		if err != nil {
+			return nil, fmt.Errorf("invalid placeholder %q in mapping", placeholder)
+		}
+		a.mapping[placeholder] = original
+		a.ids[original] = n
+		a.ids[strings.ToLower(original)] = n
+		a.next = max(a.next, n+1)
+	}
+	return a, nil
+}
+
+type match struct {
+	start, end int
+	rule       rule
+}
+
+// Anonymize returns text with every match of the rules replaced by its placeholder.
+// Where matches overlap, the earliest and then the longest one wins. Text
+// that already holds placeholder-like words is refused, as they could not be
+// told apart from the placeholders when restoring it.
+func (a *Anonymizer) Anonymize(text string) (string, error) {
+	if locs := findPlaceholders(text); len(locs) > 0 {
+		loc := locs[0]
+		return "", fmt.Errorf("text already contains the placeholder-like %q", text[loc[0]:loc[1]])
+	}
+	var matches []match
+	for _, r := range a.rules {
+		for _, loc := range r.pattern.FindAllStringIndex(text, -1) {
+			if loc[0] < loc[1] {
+				matches = append(matches, match{start: loc[0], end: loc[1], rule: r})
+			}
+		}
+	}
+	if len(matches) == 0 {
+		return text, nil
+	}
+	sort.Slice(matches, func(i, j int) bool {
+		if matches[i].start != matches[j].start {
+			return matches[i].start < matches[j].start
+		}
+		return matches[i].end > matches[j].end
+	})
+
+	var b strings.Builder
+	b.Grow(len(text))
+	prev := 0
+	for _, m := range matches {
+		if m.start < prev {
+			continue
+		}
+		b.WriteString(text[prev:m.start])
+		b.WriteString(a.placeholder(text[m.start:m.end], m.rule.literal))
+		prev = m.end
+	}
+	b.WriteString(text[prev:])
+	return b.String(), nil
+}
+
+// placeholder returns the placeholder for original, styled after its case,
+// and records it in the mapping.
+func (a *Anonymizer) placeholder(original string, literal bool) string {
+	key := original
+	if literal {
+		key = strings.ToLower(original)
+	}
+	n, ok := a.ids[key]
+	if !ok {
+		n = a.next
+		a.next++
+		a.ids[key] = n
+	}
+
+	name := placeholderPrefix + strconv.Itoa(n)
+	switch {
+	case original == strings.ToUpper(original) && original != strings.ToLower(original):
+		name = strings.ToUpper(name)
+	case original != strings.ToLower(original):
+		name = strings.ToUpper(name[:1]) + name[1:]
+	}
+	a.mapping[name] = original
+	return name
+}
+
+// Mapping returns the placeholders handed out so far and the text they replaced.
+func (a *Anonymizer) Mapping() Mapping {
+	return a.mapping
+}
+
+// Mapping maps placeholders to the text they replaced.
+type Mapping map[string]string
+
+// LoadMapping reads a mapping written by Save. A missing file yields an
+// empty mapping if allowMissing is set.
+func LoadMapping(path string, allowMissing bool) (Mapping, error) {
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) && allowMissing {
+		return Mapping{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	m := Mapping{}
+	if err := json.Unmarshal(data, &m); err != nil {
+		return nil, fmt.Errorf("invalid mapping file %s: %w", path, err)
+	}
+	return m, nil
+}
+
+// Save writes the mapping to path, readable only by its owner. The file is
+// replaced in one step, as it is the only way back to the original names.
+func (m Mapping) Save(path string) error {
+	data, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return err
+	}
+	return atomicfile.WriteFile(path, append(data, '\n'), 0600)
+}
+
+// Restore returns text with every placeholder replaced by the text it stands
+// for. Placeholders within a longer word or number, as in canon1, are left
+// alone.
+func (m Mapping) Restore(text string) string {
+	if len(m) == 0 {
+		return text
+	}
+	var b strings.Builder
+	prev := 0
+	for _, loc := range findPlaceholders(text) {
+		original, ok := m[text[loc[0]:loc[1]]]
+		if !ok {
+			continue
+		}
+		b.WriteString(text[prev:loc[0]])
+		b.WriteString(original)
+		prev = loc[1]
+	}
+	b.WriteString(text[prev:])
+	return b.String()
+}
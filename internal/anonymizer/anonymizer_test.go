@@ -0,0 +1,82 @@
+package anonymizer
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestAnonymizer(t *testing.T) {
+	dir := t.TempDir()
+	rulesPath := filepath.Join(dir, "terms")
+	rules := "# Product and customer names\nAcme\nGlobex\nre:[a-z0-9-]+\\.corp\\.example\\.com\n"
+	if err := os.WriteFile(rulesPath, []byte(rules), 0644); err != nil {
+		t.Fatalf("Failed to write rules: %v", err)
+	}
+
+	a, err := New(rulesPath, nil)
+	if err != nil {
+		t.Fatalf("New() failed: %v", err)
+	}
+
+	input := "type AcmeClient struct{}\nconst ACME_URL = \"https://db-1.corp.example.com\"\n// acme and Globex share db-1.corp.example.com\n"
+	expected := "type Anon1Client struct{}\nconst ANON1_URL = \"https://anon2\"\n// anon1 and Anon3 share anon2\n"
+	got, err := a.Anonymize(input)
+	if err != nil {
+		t.Fatalf("Anonymize() failed: %v", err)
+	}
+	if got != expected {
+		t.Fatalf("Anonymize() mismatch:\n--- EXPECTED ---\n%q\n\n--- GOT ---\n%q", expected, got)
+	}
+
+	mapPath := filepath.Join(dir, "map.json")
+	if err := a.Mapping().Save(mapPath); err != nil {
+		t.Fatalf("Save() failed: %v", err)
+	}
+	mapping, err := LoadMapping(mapPath, false)
+	if err != nil {
+		t.Fatalf("LoadMapping() failed: %v", err)
+	}
+	if restored := mapping.Restore(got); restored != input {
+		t.Errorf("Restore() mismatch:\n--- EXPECTED ---\n%q\n\n--- GOT ---\n%q", input, restored)
+	}
+
+	t.Run("placeholders are stable across runs", func(t *testing.T) {
+		again, err := New(rulesPath, mapping)
+		if err != nil {
+			t.Fatalf("New() with mapping failed: %v", err)
+		}
+		if got, _ := again.Anonymize("Globex"); got != "Anon3" {
+			t.Errorf("Anonymize(%q) = %q; want %q", "Globex", got, "Anon3")
+		}
+	})
+
+	t.Run("placeholders within words are not restored", func(t *testing.T) {
+		text := "canon1 Anon1s anon12 ANON1_URL anon3 anon1"
+		expected := "canon1 Acmes anon12 ACME_URL anon3 acme"
+		if got := mapping.Restore(text); got != expected {
+			t.Errorf("Restore(%q) = %q; want %q", text, got, expected)
+		}
+	})
+
+	t.Run("placeholder-like input is refused", func(t *testing.T) {
+		if _, err := a.Anonymize("Acme uses anon7"); err == nil {
+			t.Error("Anonymize() with placeholder-like text succeeded; want an error")
+		}
+		if _, err := a.Anonymize("canon1 Acme"); err != nil {
+			t.Errorf("Anonymize() with canon1 failed: %v", err)
+		}
+	})
+}
+
+func TestNewRejectsInvalidPlaceholders(t *testing.T) {
+	rulesPath := filepath.Join(t.TempDir(), "terms")
+	if err := os.WriteFile(rulesPath, []byte("Acme\n"), 0644); err != nil {
+		t.Fatalf("Failed to write rules: %v", err)
+	}
+	for _, placeholder := range []string{"", "an", "anon", "anon-1", "anon1x", "name1"} {
+		if _, err := New(rulesPath, Mapping{placeholder: "Acme"}); err == nil {
+			t.Errorf("New() with placeholder %q succeeded; want an error", placeholder)
+		}
+	}
+}
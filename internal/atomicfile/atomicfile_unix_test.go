@@ -0,0 +1,69 @@
+//go:build unix
+
+package atomicfile
+
+import (
+	"os"
+	"path/filepath"
+	"syscall"
+	"testing"
+)
+
+func TestWriteFileKeepsSymlinks(t *testing.T) {
+	dir := t.TempDir()
+	target := filepath.Join(dir, "target.md")
+	if err := os.WriteFile(target, []byte("old"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	link := filepath.Join(dir, "bundle.md")
+	if err := os.Symlink("target.md", link); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := WriteFile(link, []byte("new"), 0o644); err != nil {
+		t.Fatalf("WriteFile() error = %v", err)
+	}
+	if info, err := os.Lstat(link); err != nil || info.Mode()&os.ModeSymlink == 0 {
+		t.Errorf("WriteFile() replaced the symlink: %v, %v", info, err)
+	}
+	if got, _ := os.ReadFile(target); string(got) != "new" {
+		t.Errorf("target content = %q; want %q", got, "new")
+	}
+}
+
+func TestWriteFileToDevice(t *testing.T) {
+	if err := WriteFile(os.DevNull, []byte("new"), 0o644); err != nil {
+		t.Fatalf("WriteFile(%s) error = %v", os.DevNull, err)
+	}
+	if info, err := os.Stat(os.DevNull); err != nil || info.Mode()&os.ModeDevice == 0 {
+		t.Errorf("%s is no longer a device: %v, %v", os.DevNull, info, err)
+	}
+}
+
+func TestWriteFilePermissions(t *testing.T) {
+	dir := t.TempDir()
+	old := syscall.Umask(0o027)
+	defer syscall.Umask(old)
+
+	path := filepath.Join(dir, "new.md")
+	if err := WriteFile(path, []byte("new"), 0o666); err != nil {
+		t.Fatalf("WriteFile() error = %v", err)
+	}
+	if info, err := os.Stat(path); err != nil || info.Mode().Perm() != 0o640 {
+		t.Errorf("new file mode = %v, %v; want %v", info.Mode().Perm(), err, os.FileMode(0o640))
+	}
+
+	existing := filepath.Join(dir, "existing.md")
+	if err := os.WriteFile(existing, []byte("old"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chmod(existing, 0o604); err != nil {
+		t.Fatal(err)
+	}
+	if err := WriteFile(existing, []byte("new"), 0o666); err != nil {
+		t.Fatalf("WriteFile() error = %v", err)
+	}
+	if info, err := os.Stat(existing); err != nil || info.Mode().Perm() != 0o604 {
+		t.Errorf("existing file mode = %v, %v; want %v", info.Mode().Perm(), err, os.FileMode(0o604))
+	}
+}
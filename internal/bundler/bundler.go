@@ -2,23 +2,82 @@ package bundler
 
 import (
 	"bytes"
+	"cmp"
+	"context"
+	"errors"
 	"fmt"
 	"io/fs"
 	"os"
 	"path/filepath"
+	"regexp"
+	"slices"
 	"sort"
 	"strings"
 	"sync"
+	"time"
+	"unicode"
 
+	"github.com/axseem/dirmd/internal/anonymizer"
+	"github.com/axseem/dirmd/internal/atomicfile"
 	"github.com/axseem/dirmd/internal/config"
+	"github.com/axseem/dirmd/internal/detect"
+	"github.com/axseem/dirmd/internal/gitattributes"
 	"github.com/axseem/dirmd/internal/ignorer"
 	"github.com/axseem/dirmd/internal/processor"
+	"github.com/axseem/dirmd/internal/secrets"
+	"github.com/axseem/dirmd/internal/transform"
+	"github.com/axseem/dirmd/internal/unicodescan"
+	ignore "github.com/sabhiram/go-gitignore"
 )
 
 // Bundler orchestrates the file bundling process.
 type Bundler struct {
-	cfg     *config.Config
-	ignorer *ignorer.Ignorer
+	cfg        *config.Config
+	ignorer    *ignorer.Ignorer
+	attributes *gitattributes.Attributes
+	policies   map[detect.Category]detect.Policy
+	opts       processor.Options
+	secrets    *secrets.Scanner
+	// include matches the files to bundle. Nil means all of them.
+	include *ignore.GitIgnore
+	// exclude matches the files and directories to leave out.
+	exclude *ignore.GitIgnore
+	// newerThan is the time files must be modified after. Zero means any time.
+	newerThan time.Time
+	// marked holds the lines to mark by file, from the trace or coverage
+	// profile. Only the files in it are bundled, unless it is nil.
+	marked map[string][]int
+	// langs holds the languages to bundle. Nil means all of them.
+	langs map[string]bool
+	// excludeLangs holds the languages to leave out.
+	excludeLangs map[string]bool
+	// maskValues matches the files whose configuration values are masked.
+	maskValues *ignore.GitIgnore
+	// secretsMode decides what happens to secrets found by the secrets scanner.
+	secretsMode secrets.Mode
+	symlinks    symlinkPolicy
+	// realRoot is the root directory with symlinks resolved.
+	realRoot string
+
+	// followed holds the directories symlinks were followed to.
+	followed map[string]bool
+	// links maps the symlinks shown in the tree to their target.
+	links map[string]string
+	// ownFiles maps the absolute paths of the files this run writes, or reads
+	// its settings from, to what they are.
+	ownFiles map[string]string
+
+	// listed maps paths shown in the tree without content to the reason why.
+	// Directory paths end with a slash.
+	listed map[string]string
+	// skipped records the files and directories left out of the bundle's content.
+	skipped []skippedFile
+	// scores holds the relevance of the files selected for the query.
+	scores map[string]float64
+	// langExcluded counts the files left out by the language filters by language.
+	langExcluded map[string]int
+	// warnings lists the problems that were worked around, in the order they happened.
+	warnings []string
 }
 
 // New creates a new Bundler instance.
@@ -27,28 +86,134 @@ func New(cfg *config.Config) (*Bundler, error) {
 	if err != nil {
 		return nil, fmt.Errorf("failed to initialize ignorer: %w", err)
 	}
+	attrs, err := gitattributes.Load(cfg.RootDir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read .gitattributes: %w", err)
+	}
+	policies, err := categoryPolicies(cfg)
+	if err != nil {
+		return nil, err
+	}
+	opts, err := processorOptions(cfg)
+	if err != nil {
+		return nil, err
+	}
+	secretsMode, err := secrets.ParseMode(cfg.SecretsMode)
+	if err != nil {
+		return nil, err
+	}
+	var scanner *secrets.Scanner
+	if secretsMode != secrets.ModeOff {
+		scanner, err = secrets.NewScanner(cfg.SecretsAllowlistPath)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load secrets allowlist: %w", err)
+		}
+	}
+	symlinks, err := parseSymlinkPolicy(cfg.Symlinks)
+	if err != nil {
+		return nil, err
+	}
+	var newerThan time.Time
+	if cfg.NewerThan != "" {
+		if newerThan, err = parseNewerThan(cfg.NewerThan, time.Now()); err != nil {
+			return nil, err
+		}
+	}
+	realRoot, err := filepath.EvalSymlinks(cfg.RootDir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve root directory: %w", err)
+	}
+	var include *ignore.GitIgnore
+	if len(cfg.Include) > 0 {
+		include = ignore.CompileIgnoreLines(cfg.Include...)
+	}
+	own, err := ownFiles(cfg)
+	if err != nil {
+		return nil, err
+	}
+	if cfg.FromTrace != "" && cfg.Coverage != "" {
+		return nil, errors.New("--from-trace and --coverage cannot be combined")
+	}
 	return &Bundler{
-		cfg:     cfg,
-		ignorer: ign,
+		cfg:          cfg,
+		ignorer:      ign,
+		attributes:   attrs,
+		policies:     policies,
+		opts:         opts,
+		secrets:      scanner,
+		secretsMode:  secretsMode,
+		include:      include,
+		exclude:      ignore.CompileIgnoreLines(cfg.Exclude...),
+		newerThan:    newerThan,
+		langs:        languageSet(cfg.Langs),
+		excludeLangs: languageSet(cfg.ExcludeLangs),
+		maskValues:   ignore.CompileIgnoreLines(cfg.MaskValues...),
+		symlinks:     symlinks,
+		realRoot:     realRoot,
+		followed:     map[string]bool{realRoot: true},
+		links:        make(map[string]string),
+		ownFiles:     own,
+		listed:       make(map[string]string),
+		langExcluded: make(map[string]int),
+		scores:       make(map[string]float64),
 	}, nil
 }
 
-// Bundle finds, processes, and bundles all relevant files into a single markdown file.
-func (b *Bundler) Bundle() error {
+// Bundle finds, processes, and bundles all relevant files into a single
+// markdown file. It stops early, leaving any previous output untouched, if
+// ctx is cancelled.
+func (b *Bundler) Bundle(ctx context.Context) error {
+	var err error
+	switch {
+	case b.cfg.FromTrace != "":
+		b.marked, err = readTrace(ctx, b.cfg.FromTrace, b.cfg.RootDir)
+	case b.cfg.Coverage != "":
+		b.marked, err = readCoverage(ctx, b.cfg.Coverage, b.cfg.RootDir, b.cfg.CoverageTests)
+	}
+	if ctx.Err() != nil {
+		return fmt.Errorf("bundling interrupted: %w", ctx.Err())
+	}
+	if err != nil {
+		return err
+	}
+
 	fmt.Fprintln(os.Stderr, "- Collecting files...")
-	filePaths, err := b.collectFiles()
+	filePaths, err := b.collectFiles(ctx)
+	if ctx.Err() != nil {
+		return fmt.Errorf("bundling interrupted: %w", ctx.Err())
+	}
 	if err != nil {
 		return fmt.Errorf("error collecting files: %w", err)
 	}
 
 	if len(filePaths) == 0 {
 		fmt.Fprintln(os.Stderr, "No files to bundle.")
+		printWarnings(os.Stderr, b.warnings)
 		return nil
 	}
 	fmt.Fprintf(os.Stderr, "- Found %d files to bundle.\n", len(filePaths))
 
 	fmt.Fprintf(os.Stderr, "- Processing files with %d workers...\n", b.cfg.Workers)
-	results := b.processFiles(filePaths)
+	results, err := b.processFiles(ctx, filePaths)
+	if err != nil {
+		return fmt.Errorf("bundling interrupted: %w", err)
+	}
+	filePaths, err = b.dropReadErrors(filePaths, results)
+	if err != nil {
+		return err
+	}
+	filePaths = b.skipUnread(filePaths, results)
+	filePaths = b.filterLanguages(filePaths, results)
+	filePaths = b.applyContentPolicies(filePaths, results)
+	if b.cfg.Query != "" {
+		filePaths = b.selectByQuery(filePaths, results)
+	}
+	if err := b.reportSecrets(filePaths, results); err != nil {
+		return err
+	}
+	if err := b.reportUnicode(filePaths, results); err != nil {
+		return err
+	}
 
 	fmt.Fprintln(os.Stderr, "- Assembling markdown file...")
 	markdownContent, err := b.assembleMarkdown(filePaths, results)
@@ -56,27 +221,71 @@ func (b *Bundler) Bundle() error {
 		return fmt.Errorf("error assembling markdown: %w", err)
 	}
 
+	var mapping anonymizer.Mapping
+	if b.cfg.AnonymizeRulesPath != "" {
+		markdownContent, mapping, err = b.anonymize(markdownContent)
+		if err != nil {
+			return fmt.Errorf("error anonymizing bundle: %w", err)
+		}
+	}
+
+	if err := ctx.Err(); err != nil {
+		return fmt.Errorf("bundling interrupted: %w", err)
+	}
+	if mapping != nil {
+		// The mapping is saved first, so that the bundle never holds
+		// placeholders it lacks.
+		if err := mapping.Save(b.cfg.AnonymizeMapPath); err != nil {
+			return fmt.Errorf("error anonymizing bundle: %w", err)
+		}
+		fmt.Fprintf(os.Stderr, "- Anonymized bundle, mapping written to %s\n", b.cfg.AnonymizeMapPath)
+	}
 	if b.cfg.OutputPath != "" {
-		err = os.WriteFile(b.cfg.OutputPath, []byte(markdownContent), 0644)
+		err = atomicfile.WriteFile(b.cfg.OutputPath, []byte(markdownContent), 0644)
 		if err != nil {
 			return fmt.Errorf("error writing to output file %s: %w", b.cfg.OutputPath, err)
 		}
 		fmt.Fprintf(os.Stderr, "- Successfully bundled project to %s\n", b.cfg.OutputPath)
-		return nil
-	}
-	_, err = fmt.Fprint(os.Stdout, markdownContent)
-	if err != nil {
-		return fmt.Errorf("error writing to standard output: %w", err)
+	} else {
+		_, err = fmt.Fprint(os.Stdout, markdownContent)
+		if err != nil {
+			return fmt.Errorf("error writing to standard output: %w", err)
+		}
 	}
 
+	st := newStats(b.cfg.RootDir, filePaths, results, markdownContent)
+	st.Skipped = b.skipped
+	st.Warnings = b.warnings
+	st.addExcludedLanguages(b.langExcluded)
+	st.print(os.Stderr)
+	if b.cfg.ReportPath != "" {
+		if err := st.writeJSON(b.cfg.ReportPath); err != nil {
+			return fmt.Errorf("error writing report %s: %w", b.cfg.ReportPath, err)
+		}
+	}
 	return nil
 }
 
-func (b *Bundler) collectFiles() ([]string, error) {
+func (b *Bundler) collectFiles(ctx context.Context) ([]string, error) {
 	var files []string
-	err := filepath.WalkDir(b.cfg.RootDir, func(path string, d fs.DirEntry, err error) error {
+	if err := b.walk(ctx, b.cfg.RootDir, b.cfg.RootDir, &files); err != nil {
+		return nil, err
+	}
+	sort.Strings(files)
+	return files, nil
+}
+
+// walk adds the files to bundle below dir to files. The paths are reported
+// below displayDir instead, which differs from dir for followed symlinks.
+// It stops with the error of ctx once ctx is cancelled.
+func (b *Bundler) walk(ctx context.Context, dir, displayDir string, files *[]string) error {
+	return filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		path = displayDir + path[len(dir):]
 		if err != nil {
-			return err
+			return b.walkError(path, d, err)
 		}
 		relativePath, err := filepath.Rel(b.cfg.RootDir, path)
 		if err != nil {
@@ -87,7 +296,7 @@ func (b *Bundler) collectFiles() ([]string, error) {
 			return nil
 		}
 
-		if b.ignorer.IsIgnored(relativePath) {
+		if b.ignorer.IsIgnored(relativePath) || b.exclude.MatchesPath(relativePath) {
 			if d.IsDir() {
 				return filepath.SkipDir
 			}
@@ -101,19 +310,120 @@ func (b *Bundler) collectFiles() ([]string, error) {
 			return nil
 		}
 
-		if !d.IsDir() {
-			files = append(files, path)
+		if d.Type()&fs.ModeSymlink != 0 {
+			return b.visitSymlink(ctx, path, files)
+		}
+
+		if kind := processor.SpecialFileKind(d.Type()); kind != "" {
+			b.skip(path, kind, false)
+			return nil
+		}
+
+		if what, ok := b.ownFiles[path]; ok {
+			b.warn("skipped %s: it is the %s of this run", relativePath, what)
+			return nil
+		}
+
+		if d.IsDir() {
+			if b.tooDeep(path, relativePath) {
+				return filepath.SkipDir
+			}
+			if category := b.pathCategory(relativePath, true); category != "" && b.applyPolicy(path, category, true) {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+
+		if b.isIncluded(path) && b.passesFilters(path, d.Info) && !b.applyPathPolicy(path, relativePath) {
+			*files = append(*files, path)
 		}
 		return nil
 	})
+}
+
+// walkError handles an error the walk met at path, where d may be nil. In
+// strict mode, or for the root, the walk stops with the error. Otherwise the
+// entry is left out with a warning and the walk goes on.
+func (b *Bundler) walkError(path string, d fs.DirEntry, err error) error {
+	// The walk can go on without an unreadable entry, but not without the root.
+	if b.cfg.Strict || path == b.cfg.RootDir {
+		return err
+	}
+	b.warn("skipped %s: %v", b.relPath(path), err)
+	if d != nil && d.IsDir() {
+		return filepath.SkipDir
+	}
+	return nil
+}
+
+// ownFiles resolves the paths of the files cfg makes dirmd write, and of
+// those it reads that must not be bundled.
+func ownFiles(cfg *config.Config) (map[string]string, error) {
+	// The anonymization rules and the secrets allowlist name what must not
+	// show in the bundle.
+	files := map[string]string{
+		cfg.OutputPath:           "output",
+		cfg.ReportPath:           "report",
+		cfg.AnonymizeMapPath:     "anonymization map",
+		cfg.AnonymizeRulesPath:   "anonymization rules file",
+		cfg.SecretsAllowlistPath: "secrets allowlist",
+	}
+	abs := make(map[string]string, len(files))
+	for path, what := range files {
+		if path == "" {
+			continue
+		}
+		path, err := filepath.Abs(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to resolve %s path: %w", what, err)
+		}
+		abs[path] = what
+	}
+	return abs, nil
+}
+
+// anonymize replaces the names matched by the anonymization rules throughout
+// the bundle. It returns the mapping file's mapping with the new placeholders
+// added, which is left to the caller to save.
+func (b *Bundler) anonymize(markdown string) (string, anonymizer.Mapping, error) {
+	mapping, err := anonymizer.LoadMapping(b.cfg.AnonymizeMapPath, true)
 	if err != nil {
-		return nil, err
+		return "", nil, err
 	}
-	sort.Strings(files)
-	return files, nil
+	anon, err := anonymizer.New(b.cfg.AnonymizeRulesPath, mapping)
+	if err != nil {
+		return "", nil, err
+	}
+	// The header is left alone, so that the bundle is still recognised.
+	header, body, _, _ := processor.ParseBundleHeader(markdown)
+	body, err = anon.Anonymize(body)
+	if err != nil {
+		return "", nil, fmt.Errorf("cannot anonymize the bundle: %w", err)
+	}
+	return header + body, anon.Mapping(), nil
+}
+
+// isIncluded reports whether the file at path matches the include patterns
+// and, if there is a trace or coverage profile, is one of the files it selects.
+func (b *Bundler) isIncluded(path string) bool {
+	if _, ok := b.marked[path]; b.marked != nil && !ok {
+		return false
+	}
+	return b.include == nil || b.include.MatchesPath(b.relPath(path))
+}
+
+// relPath returns path relative to the root directory, with forward slashes.
+func (b *Bundler) relPath(path string) string {
+	relPath, err := filepath.Rel(b.cfg.RootDir, path)
+	if err != nil {
+		relPath = path
+	}
+	return filepath.ToSlash(relPath)
 }
 
-func (b *Bundler) processFiles(paths []string) map[string]processor.Result {
+// processFiles processes paths with a pool of workers. Once ctx is
+// cancelled, the workers stop taking new files and the error of ctx is returned.
+func (b *Bundler) processFiles(ctx context.Context, paths []string) (map[string]processor.Result, error) {
 	jobs := make(chan string, len(paths))
 	resultsChan := make(chan processor.Result, len(paths))
 	var wg sync.WaitGroup
@@ -123,7 +433,19 @@ func (b *Bundler) processFiles(paths []string) map[string]processor.Result {
 		go func() {
 			defer wg.Done()
 			for path := range jobs {
-				resultsChan <- processor.ProcessFile(path)
+				if ctx.Err() != nil {
+					continue
+				}
+				opts := b.opts
+				opts.MaskValues = b.maskValues.MatchesPath(b.relPath(path))
+				opts.Language, _ = b.attributes.Value(b.relPath(path), "linguist-language")
+				opts.Lines = b.marked[path]
+				if b.secrets != nil {
+					opts.Scan = func(content []byte, lang string, category detect.Category) ([]processor.Finding, []byte) {
+						return b.scanSecrets(path, content, lang, category)
+					}
+				}
+				resultsChan <- processor.ProcessFile(path, opts)
 			}
 		}()
 	}
@@ -133,22 +455,146 @@ func (b *Bundler) processFiles(paths []string) map[string]processor.Result {
 	}
 	close(jobs)
 
-	wg.Wait()
+	// Workers stuck in a read are not waited for once ctx is cancelled.
+	// The channels are buffered, so they end on their own.
+	done := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
 	close(resultsChan)
 
 	resultsMap := make(map[string]processor.Result)
 	for res := range resultsChan {
 		resultsMap[res.Path] = res
 	}
-	return resultsMap
+	return resultsMap, nil
+}
+
+// dropReadErrors removes the files that could not be read from paths and
+// records a warning for each. In strict mode, it fails on the first one.
+func (b *Bundler) dropReadErrors(paths []string, results map[string]processor.Result) ([]string, error) {
+	for _, path := range paths {
+		if err := results[path].ReadError; err != nil && b.cfg.Strict {
+			return nil, fmt.Errorf("could not process file %s: %w", b.relPath(path), err)
+		}
+	}
+	return slices.DeleteFunc(paths, func(path string) bool {
+		err := results[path].ReadError
+		if err != nil {
+			b.warn("could not process file %s: %v", b.relPath(path), err)
+		}
+		return err != nil
+	}), nil
+}
+
+// warn records a problem that was worked around, to be summarized at the end.
+func (b *Bundler) warn(format string, args ...any) {
+	b.warnings = append(b.warnings, fmt.Sprintf(format, args...))
+}
+
+// skipUnread removes the files whose content was left out while reading
+// them from paths, and records them as skipped. Earlier bundles are
+// reported as warnings instead.
+func (b *Bundler) skipUnread(paths []string, results map[string]processor.Result) []string {
+	return slices.DeleteFunc(paths, func(path string) bool {
+		switch reason := results[path].SkipReason; reason {
+		case "":
+			return false
+		case processor.SkipBundle:
+			b.warn("skipped %s: it is a dirmd bundle", b.relPath(path))
+		default:
+			b.skip(path, reason, false)
+		}
+		return true
+	})
+}
+
+func processorOptions(cfg *config.Config) (processor.Options, error) {
+	strategy, err := transform.ParseTruncateStrategy(cfg.TruncateStrategy)
+	if err != nil {
+		return processor.Options{}, err
+	}
+	var match *regexp.Regexp
+	if cfg.TruncateMatch != "" {
+		match, err = regexp.Compile(cfg.TruncateMatch)
+		if err != nil {
+			return processor.Options{}, fmt.Errorf("invalid truncate pattern: %w", err)
+		}
+	} else if strategy == transform.TruncateHeadTailMatch {
+		return processor.Options{}, fmt.Errorf("truncation strategy %s requires a pattern", strategy)
+	}
+
+	// Uncovered lines are marked in whole files.
+	linesContext := cfg.TraceContext
+	if cfg.FromTrace == "" {
+		linesContext = -1
+	}
+
+	var grep *regexp.Regexp
+	if cfg.Grep != "" {
+		if grep, err = regexp.Compile(cfg.Grep); err != nil {
+			return processor.Options{}, fmt.Errorf("invalid grep pattern: %w", err)
+		}
+	}
+
+	unicodeCheck, err := unicodescan.ParseMode(cfg.UnicodeCheck)
+	if err != nil {
+		return processor.Options{}, err
+	}
+
+	languages := make(map[string]string, len(cfg.Languages))
+	for name, lang := range cfg.Languages {
+		languages[strings.ToLower(name)] = lang
+	}
+
+	return processor.Options{
+		Languages:              languages,
+		MaxReadBytes:           cfg.MaxReadBytes,
+		MaxLines:               cfg.ExcludeLongerThan,
+		ReadTimeout:            cfg.ReadTimeout,
+		UnicodeCheck:           unicodeCheck,
+		NormalizeNewlines:      cfg.NormalizeNewlines,
+		StripComments:          cfg.StripComments,
+		KeepDocComments:        cfg.KeepDocComments,
+		TrimTrailingWhitespace: cfg.TrimTrailingWhitespace,
+		CollapseBlankLines:     cfg.CollapseBlankLines,
+		IndentWithTabs:         cfg.IndentWithTabs,
+		TabWidth:               cfg.TabWidth,
+		Grep:                   grep,
+		GrepContext:            cfg.GrepContext,
+		LinesContext:           linesContext,
+		Limits: transform.Limits{
+			MaxLines: cfg.MaxFileLines,
+			MaxBytes: cfg.MaxFileBytes,
+			Strategy: strategy,
+			Match:    match,
+		},
+		LineNumbers:         cfg.LineNumbers,
+		LineNumberFormat:    cfg.LineNumberFormat,
+		LineNumbersRelative: cfg.LineNumbersRelative,
+	}, nil
 }
 
 type treeNode struct {
 	children map[string]*treeNode
 	isDir    bool
+	// note is shown next to the entry, such as why its content is incomplete.
+	note string
+	// target is what the entry points to, if it is a symlink.
+	target string
 }
 
-func generateFileTree(rootDir string, paths []string) string {
+// generateFileTree renders the structure of paths below rootDir as a
+// markdown list. Entries found in notes are annotated with their note, and
+// those found in links are shown as symlinks to their target. Paths ending
+// with a slash are shown as directories.
+func generateFileTree(rootDir string, paths []string, notes, links map[string]string) string {
 	root := &treeNode{children: make(map[string]*treeNode), isDir: true}
 	for _, path := range paths {
 		relPath, err := filepath.Rel(rootDir, path)
@@ -166,6 +612,11 @@ func generateFileTree(rootDir string, paths []string) string {
 				currentNode.isDir = true
 			}
 		}
+		currentNode.note = notes[path]
+		currentNode.target = links[path]
+		if strings.HasSuffix(path, "/") {
+			currentNode.isDir = true
+		}
 	}
 
 	var builder strings.Builder
@@ -190,7 +641,14 @@ func buildTreeStringRecursive(builder *strings.Builder, node *treeNode, prefix s
 		if childNode.isDir {
 			name += "/"
 		}
-		fmt.Fprintf(builder, "%s- `%s`\n", prefix, name)
+		switch {
+		case childNode.target != "":
+			fmt.Fprintf(builder, "%s- `%s -> %s`\n", prefix, name, childNode.target)
+		case childNode.note != "":
+			fmt.Fprintf(builder, "%s- `%s` (%s)\n", prefix, name, childNode.note)
+		default:
+			fmt.Fprintf(builder, "%s- `%s`\n", prefix, name)
+		}
 		if len(childNode.children) > 0 {
 			buildTreeStringRecursive(builder, childNode, prefix+"  ")
 		}
@@ -198,9 +656,42 @@ func buildTreeStringRecursive(builder *strings.Builder, node *treeNode, prefix s
 }
 
 func (b *Bundler) assembleMarkdown(sortedPaths []string, results map[string]processor.Result) (string, error) {
-	var markdownParts []string
-
-	tree := generateFileTree(b.cfg.RootDir, sortedPaths)
+	lineNumberFormat := ""
+	for _, path := range sortedPaths {
+		if results[path].LineNumbers {
+			lineNumberFormat = cmp.Or(b.opts.LineNumberFormat, transform.DefaultLineNumberFormat)
+			break
+		}
+	}
+	markdownParts := []string{processor.BundleHeader(lineNumberFormat)}
+
+	notes := make(map[string]string)
+	for path, result := range results {
+		switch {
+		case result.Truncated:
+			notes[path] = fmt.Sprintf("truncated, %d of %d lines omitted", result.OmittedLines, result.TotalLines)
+		case result.Excerpted:
+			notes[path] = fmt.Sprintf("excerpt, %d of %d lines omitted", result.OmittedLines, result.TotalLines)
+		case b.cfg.Coverage != "" && len(b.marked[path]) > 0:
+			notes[path] = fmt.Sprintf("%d uncovered lines", len(b.marked[path]))
+		}
+	}
+	for path, score := range b.scores {
+		note := fmt.Sprintf("score %.2f", score)
+		if notes[path] != "" {
+			note += ", " + notes[path]
+		}
+		notes[path] = note
+	}
+	treePaths := slices.Clone(sortedPaths)
+	for path, note := range b.listed {
+		treePaths = append(treePaths, path)
+		notes[path] = note
+	}
+	for path := range b.links {
+		treePaths = append(treePaths, path)
+	}
+	tree := generateFileTree(b.cfg.RootDir, treePaths, notes, b.links)
 	markdownParts = append(markdownParts, tree)
 
 	for _, path := range sortedPaths {
@@ -209,10 +700,6 @@ func (b *Bundler) assembleMarkdown(sortedPaths []string, results map[string]proc
 			return "", fmt.Errorf("internal error: result not found for path %s", path)
 		}
 
-		if result.ReadError != nil {
-			fmt.Fprintf(os.Stderr, "- Could not process file %s: %v\n", path, result.ReadError)
-			continue
-		}
 		if result.IsBinary {
 			fmt.Fprintf(os.Stderr, "- Skipping binary file: %s\n", path)
 			continue
@@ -227,7 +714,8 @@ func (b *Bundler) assembleMarkdown(sortedPaths []string, results map[string]proc
 		var fileContentBuilder strings.Builder
 		fileContentBuilder.WriteString("`" + relPath + "`\n")
 		fileContentBuilder.WriteString(fmt.Sprintf("```%s\n", result.Language))
-		fileContentBuilder.Write(bytes.TrimSpace(result.Content))
+		// Leading blanks are kept so that the first line keeps its indentation.
+		fileContentBuilder.Write(bytes.TrimRightFunc(bytes.TrimLeft(result.Content, "\r\n"), unicode.IsSpace))
 		fileContentBuilder.WriteString("\n```")
 
 		markdownParts = append(markdownParts, fileContentBuilder.String())
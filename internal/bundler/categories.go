@@ -0,0 +1,130 @@
+package bundler
+
+import (
+	"fmt"
+	"slices"
+
+	"github.com/axseem/dirmd/internal/config"
+	"github.com/axseem/dirmd/internal/detect"
+	"github.com/axseem/dirmd/internal/processor"
+)
+
+func categoryPolicies(cfg *config.Config) (map[detect.Category]detect.Policy, error) {
+	policies := make(map[detect.Category]detect.Policy)
+	for category, name := range map[detect.Category]string{
+		detect.Generated: cfg.GeneratedPolicy,
+		detect.Vendored:  cfg.VendoredPolicy,
+		detect.Minified:  cfg.MinifiedPolicy,
+	} {
+		policy, err := detect.ParsePolicy(name)
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s files policy: %w", category, err)
+		}
+		policies[category] = policy
+	}
+	return policies, nil
+}
+
+// pathCategory classifies a file or directory by its slash-separated path
+// relative to the root and by the linguist attributes in .gitattributes.
+// It returns an empty category for ordinary files.
+func (b *Bundler) pathCategory(relPath string, isDir bool) detect.Category {
+	if isDir {
+		if detect.IsVendoredDir(relPath) {
+			return detect.Vendored
+		}
+		return ""
+	}
+	if v, ok := b.attributes.Value(relPath, "linguist-generated"); ok {
+		if v == "true" {
+			return detect.Generated
+		}
+	} else if detect.IsLockfile(relPath) {
+		return detect.Generated
+	}
+	if v, ok := b.attributes.Value(relPath, "linguist-vendored"); ok {
+		if v == "true" {
+			return detect.Vendored
+		}
+	} else if detect.IsVendoredPath(relPath) {
+		return detect.Vendored
+	}
+	return ""
+}
+
+// applyPolicy handles a file or directory of the given category according
+// to its policy and reports whether it must be left out of the walk.
+func (b *Bundler) applyPolicy(path string, category detect.Category, isDir bool) bool {
+	switch b.policies[category] {
+	case detect.Exclude:
+		b.skip(path, string(category), false)
+		return true
+	case detect.List:
+		// A query selects files by their content, which listed entries lack.
+		if b.cfg.Query != "" {
+			b.skip(path, string(category), false)
+			return true
+		}
+		// The directory may hold none of the selected files, which are
+		// listed on their own instead.
+		if isDir && b.selective() {
+			return false
+		}
+		if isDir {
+			path += "/"
+		}
+		b.listed[path] = string(category)
+		b.skip(path, string(category), true)
+		return true
+	}
+	return false
+}
+
+// applyPathPolicy applies the policy of the category the file at path is in
+// by its slash-separated path relative to the root, if any, and reports
+// whether the file must be left out of the files to process. It is only
+// called for selected files, so that only those are listed in the tree.
+func (b *Bundler) applyPathPolicy(path, relPath string) bool {
+	category := b.pathCategory(relPath, false)
+	if category == "" {
+		return false
+	}
+	// Listed files are not processed, which the language filters need, so
+	// their language is told from their path.
+	if b.policies[category] == detect.List {
+		opts := b.opts
+		opts.Language, _ = b.attributes.Value(relPath, "linguist-language")
+		if lang := processor.PathLanguage(path, opts); !b.selectsLanguage(lang) {
+			b.excludeLanguage(path, lang)
+			return true
+		}
+	}
+	return b.applyPolicy(path, category, false)
+}
+
+// selective reports whether files are selected by more than their path, so
+// that a directory may hold none of them.
+func (b *Bundler) selective() bool {
+	return b.include != nil || b.marked != nil || b.langs != nil || b.excludeLangs != nil ||
+		b.cfg.ExcludeLargerThan > 0 || !b.newerThan.IsZero()
+}
+
+// applyContentPolicies applies the category policies to files the processor
+// classified by their content, and returns the paths whose content is still
+// to be bundled.
+func (b *Bundler) applyContentPolicies(paths []string, results map[string]processor.Result) []string {
+	return slices.DeleteFunc(paths, func(path string) bool {
+		category := results[path].Category
+		return category != "" && b.applyPolicy(path, category, false)
+	})
+}
+
+// skip records that path was left out of the bundle's content for reason.
+// Listed entries still appear in the tree.
+func (b *Bundler) skip(path, reason string, listed bool) {
+	relPath := b.relPath(path)
+	if listed && path[len(path)-1] == '/' {
+		relPath += "/"
+	}
+	b.skipped = append(b.skipped, skippedFile{Path: relPath, Reason: reason, Listed: listed})
+}
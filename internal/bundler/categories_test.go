@@ -0,0 +1,91 @@
+package bundler
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"testing"
+
+	"github.com/axseem/dirmd/internal/config"
+)
+
+func TestListedEntriesAreSelected(t *testing.T) {
+	root := t.TempDir()
+	for _, name := range []string{"main.go", "package-lock.json", "vendor/lib/lib.go", "node_modules/x/index.js"} {
+		path := filepath.Join(root, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte("x\n"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	testCases := []struct {
+		name     string
+		setup    func(cfg *config.Config)
+		marked   map[string][]int
+		expected []string
+	}{
+		{
+			name:     "Everything is selected",
+			expected: []string{"node_modules/", "package-lock.json", "vendor/"},
+		},
+		{
+			name:     "Include patterns",
+			setup:    func(cfg *config.Config) { cfg.Include = []string{"*.go"} },
+			expected: []string{"vendor/lib/lib.go"},
+		},
+		{
+			name:     "Languages",
+			setup:    func(cfg *config.Config) { cfg.Langs = []string{"json"} },
+			expected: []string{"package-lock.json"},
+		},
+		{
+			name:     "Depth limit",
+			setup:    func(cfg *config.Config) { cfg.MaxDepth = 1 },
+			expected: []string{"package-lock.json"},
+		},
+		{
+			name:   "Trace",
+			marked: map[string][]int{filepath.Join(root, "main.go"): {1}},
+		},
+		{
+			name:  "Query",
+			setup: func(cfg *config.Config) { cfg.Query = "lib" },
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			cfg := config.NewDefaultConfig()
+			cfg.RootDir = root
+			cfg.OutputPath = ""
+			if tc.setup != nil {
+				tc.setup(cfg)
+			}
+			b, err := New(cfg)
+			if err != nil {
+				t.Fatalf("New() error = %v", err)
+			}
+			b.marked = tc.marked
+			if _, err := b.collectFiles(context.Background()); err != nil {
+				t.Fatalf("collectFiles() error = %v", err)
+			}
+			var listed []string
+			for path := range b.listed {
+				rel := b.relPath(path)
+				if path[len(path)-1] == '/' {
+					rel += "/"
+				}
+				listed = append(listed, rel)
+			}
+			sort.Strings(listed)
+			if !reflect.DeepEqual(listed, tc.expected) {
+				t.Errorf("listed %v; want %v", listed, tc.expected)
+			}
+		})
+	}
+}
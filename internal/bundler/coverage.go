@@ -0,0 +1,82 @@
+package bundler
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/axseem/dirmd/internal/coverage"
+)
+
+// readCoverage reads the Go coverage profile at path and returns the lines
+// never run by file under root. With tests set, the test files of the
+// packages of those files are added without lines.
+func readCoverage(ctx context.Context, path, root string, tests bool) (map[string][]int, error) {
+	data, err := readInput(ctx, path)
+	if err != nil {
+		return nil, fmt.Errorf("cannot read coverage profile: %w", err)
+	}
+	blocks, err := coverage.Parse(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+
+	moduleDir, modulePath, err := findModule(root)
+	if err != nil {
+		return nil, err
+	}
+	lines := make(map[string][]int)
+	// The profile names files by import path, which is the module path
+	// followed by the path of the file below the module directory.
+	for name, uncovered := range coverage.Uncovered(blocks) {
+		rel, ok := strings.CutPrefix(name, modulePath+"/")
+		if !ok {
+			continue
+		}
+		file := filepath.Join(moduleDir, filepath.FromSlash(rel))
+		if info, err := os.Stat(file); err == nil && info.Mode().IsRegular() && isWithin(root, file) {
+			lines[file] = uncovered
+		}
+	}
+	fmt.Fprintf(os.Stderr, "- Found %d files with uncovered code.\n", len(lines))
+	if tests {
+		for file := range lines {
+			testFiles, err := filepath.Glob(filepath.Join(filepath.Dir(file), "*_test.go"))
+			if err != nil {
+				return nil, err
+			}
+			for _, testFile := range testFiles {
+				if _, ok := lines[testFile]; !ok {
+					lines[testFile] = []int{}
+				}
+			}
+		}
+	}
+	return lines, nil
+}
+
+// findModule returns the directory and the path of the Go module root is in,
+// from the nearest go.mod file in root or above it.
+func findModule(root string) (dir, path string, err error) {
+	for dir = root; ; dir = filepath.Dir(dir) {
+		data, err := os.ReadFile(filepath.Join(dir, "go.mod"))
+		if err == nil {
+			path, ok := coverage.ModulePath(data)
+			if !ok {
+				return "", "", fmt.Errorf("%s: no module path", filepath.Join(dir, "go.mod"))
+			}
+			return dir, path, nil
+		}
+		if !errors.Is(err, fs.ErrNotExist) {
+			return "", "", err
+		}
+		if filepath.Dir(dir) == dir {
+			return "", "", fmt.Errorf("no go.mod in %s or above it, which the coverage profile needs", root)
+		}
+	}
+}
@@ -0,0 +1,53 @@
+package bundler
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestReadCoverage(t *testing.T) {
+	root := t.TempDir()
+	files := map[string]string{
+		"go.mod":              "module example.com/app\n\ngo 1.24\n",
+		"main.go":             "",
+		"cmd/main.go":         "",
+		"store/store.go":      "",
+		"store/store_test.go": "",
+	}
+	for name, content := range files {
+		path := filepath.Join(root, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	profile := filepath.Join(t.TempDir(), "cover.out")
+	content := "mode: set\nexample.com/app/main.go:3.13,4.2 1 0\nexample.com/app/cmd/main.go:3.13,5.2 1 1\nexample.com/app/store/store.go:7.20,8.2 1 0\nexample.com/other/main.go:1.1,2.2 1 0\n"
+	if err := os.WriteFile(profile, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	main := filepath.Join(root, "main.go")
+	store := filepath.Join(root, "store", "store.go")
+	lines, err := readCoverage(context.Background(), profile, root, false)
+	if expected := map[string][]int{main: {3, 4}, store: {7, 8}}; err != nil || !reflect.DeepEqual(lines, expected) {
+		t.Errorf("readCoverage() = %v, %v; want %v", lines, err, expected)
+	}
+
+	lines, err = readCoverage(context.Background(), profile, root, true)
+	expected := map[string][]int{main: {3, 4}, store: {7, 8}, filepath.Join(root, "store", "store_test.go"): {}}
+	if err != nil || !reflect.DeepEqual(lines, expected) {
+		t.Errorf("readCoverage() with tests = %v, %v; want %v", lines, err, expected)
+	}
+
+	// A root below the module directory only keeps the files under it.
+	lines, err = readCoverage(context.Background(), profile, filepath.Join(root, "store"), false)
+	if expected := map[string][]int{store: {7, 8}}; err != nil || !reflect.DeepEqual(lines, expected) {
+		t.Errorf("readCoverage() below the module = %v, %v; want %v", lines, err, expected)
+	}
+}
@@ -0,0 +1,67 @@
+package bundler
+
+import (
+	"fmt"
+	"io/fs"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// newerThanLayouts are the date formats accepted by --newer-than.
+var newerThanLayouts = []string{time.RFC3339, "2006-01-02T15:04", "2006-01-02"}
+
+// parseNewerThan parses a date or a duration before now, such as 7d or 12h,
+// into the time files must be modified after.
+func parseNewerThan(value string, now time.Time) (time.Time, error) {
+	for _, layout := range newerThanLayouts {
+		if t, err := time.ParseInLocation(layout, value, time.Local); err == nil {
+			return t, nil
+		}
+	}
+	// time.ParseDuration has no units longer than hours.
+	for suffix, unit := range map[string]time.Duration{"d": 24 * time.Hour, "w": 7 * 24 * time.Hour} {
+		if n, ok := strings.CutSuffix(value, suffix); ok {
+			if days, err := strconv.ParseFloat(n, 64); err == nil && days >= 0 {
+				return now.Add(-time.Duration(days * float64(unit))), nil
+			}
+		}
+	}
+	if d, err := time.ParseDuration(value); err == nil && d >= 0 {
+		return now.Add(-d), nil
+	}
+	return time.Time{}, fmt.Errorf("invalid modification time %q (want a date such as 2006-01-02 or a duration such as 7d)", value)
+}
+
+// tooDeep reports whether the directory at relPath is at the depth limit, so
+// its entries are left out, and records it as skipped.
+func (b *Bundler) tooDeep(path, relPath string) bool {
+	if b.cfg.MaxDepth <= 0 || strings.Count(relPath, "/")+1 < b.cfg.MaxDepth {
+		return false
+	}
+	b.skip(path, fmt.Sprintf("deeper than %d levels", b.cfg.MaxDepth), false)
+	return true
+}
+
+// passesFilters reports whether the file at path passes the size and
+// modification time filters, and records it as skipped otherwise. stat is
+// only called if a filter is set.
+func (b *Bundler) passesFilters(path string, stat func() (fs.FileInfo, error)) bool {
+	if b.cfg.ExcludeLargerThan <= 0 && b.newerThan.IsZero() {
+		return true
+	}
+	info, err := stat()
+	if err != nil {
+		b.warn("skipped %s: %v", b.relPath(path), err)
+		return false
+	}
+	if limit := b.cfg.ExcludeLargerThan; limit > 0 && info.Size() > int64(limit)<<10 {
+		b.skip(path, fmt.Sprintf("larger than %d KB", limit), false)
+		return false
+	}
+	if !b.newerThan.IsZero() && !info.ModTime().After(b.newerThan) {
+		b.skip(path, "not modified since "+b.newerThan.Format("2006-01-02 15:04"), false)
+		return false
+	}
+	return true
+}
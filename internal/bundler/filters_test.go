@@ -0,0 +1,43 @@
+package bundler
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseNewerThan(t *testing.T) {
+	now := time.Date(2026, 10, 19, 12, 0, 0, 0, time.Local)
+	testCases := []struct {
+		value    string
+		expected time.Time
+		wantErr  bool
+	}{
+		{value: "7d", expected: now.AddDate(0, 0, -7)},
+		{value: "1.5d", expected: now.Add(-36 * time.Hour)},
+		{value: "2w", expected: now.AddDate(0, 0, -14)},
+		{value: "12h30m", expected: now.Add(-12*time.Hour - 30*time.Minute)},
+		{value: "2026-10-01", expected: time.Date(2026, 10, 1, 0, 0, 0, 0, time.Local)},
+		{value: "2026-10-01T08:30", expected: time.Date(2026, 10, 1, 8, 30, 0, 0, time.Local)},
+		{value: "2026-10-01T08:30:00Z", expected: time.Date(2026, 10, 1, 8, 30, 0, 0, time.UTC)},
+		{value: "-3d", wantErr: true},
+		{value: "yesterday", wantErr: true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.value, func(t *testing.T) {
+			got, err := parseNewerThan(tc.value, now)
+			if tc.wantErr {
+				if err == nil {
+					t.Errorf("parseNewerThan(%q) = %v; want an error", tc.value, got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("parseNewerThan(%q) error = %v", tc.value, err)
+			}
+			if !got.Equal(tc.expected) {
+				t.Errorf("parseNewerThan(%q) = %v; want %v", tc.value, got, tc.expected)
+			}
+		})
+	}
+}
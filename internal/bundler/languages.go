@@ -0,0 +1,57 @@
+package bundler
+
+import (
+	"slices"
+	"strings"
+
+	"github.com/axseem/dirmd/internal/processor"
+)
+
+// skipLanguage is the reason files left out by the language filters are skipped.
+const skipLanguage = "language"
+
+// unknownLanguage stands for files without a detected language in the report.
+const unknownLanguage = "unknown"
+
+// languageSet builds a set of lowercase language names. It is nil if there are none.
+func languageSet(langs []string) map[string]bool {
+	if len(langs) == 0 {
+		return nil
+	}
+	set := make(map[string]bool, len(langs))
+	for _, lang := range langs {
+		set[strings.ToLower(strings.TrimSpace(lang))] = true
+	}
+	return set
+}
+
+// filterLanguages removes the files whose detected language is not selected
+// or is excluded from paths, and records them as skipped.
+func (b *Bundler) filterLanguages(paths []string, results map[string]processor.Result) []string {
+	if b.langs == nil && b.excludeLangs == nil {
+		return paths
+	}
+	return slices.DeleteFunc(paths, func(path string) bool {
+		lang := results[path].Language
+		if b.selectsLanguage(lang) {
+			return false
+		}
+		b.excludeLanguage(path, lang)
+		return true
+	})
+}
+
+// selectsLanguage reports whether the language filters keep files in lang.
+func (b *Bundler) selectsLanguage(lang string) bool {
+	return (b.langs == nil || b.langs[lang]) && !b.excludeLangs[lang]
+}
+
+// excludeLanguage records that the file at path, in lang, was left out by
+// the language filters.
+func (b *Bundler) excludeLanguage(path, lang string) {
+	if lang == "" {
+		lang = unknownLanguage
+	}
+	b.langExcluded[lang]++
+	b.skip(path, skipLanguage, false)
+}
@@ -0,0 +1,74 @@
+package bundler
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/axseem/dirmd/internal/config"
+	"github.com/axseem/dirmd/internal/processor"
+)
+
+func TestFilterLanguages(t *testing.T) {
+	results := map[string]processor.Result{
+		"/root/main.go":     {Language: "go"},
+		"/root/schema.sql":  {Language: "sql"},
+		"/root/data.json":   {Language: "json"},
+		"/root/LICENSE":     {},
+		"/root/config.yaml": {Language: "yaml"},
+	}
+	paths := []string{"/root/LICENSE", "/root/config.yaml", "/root/data.json", "/root/main.go", "/root/schema.sql"}
+
+	testCases := []struct {
+		name             string
+		langs            []string
+		excludeLangs     []string
+		expectedPaths    []string
+		expectedExcluded map[string]int
+	}{
+		{
+			name:             "no filters",
+			expectedPaths:    paths,
+			expectedExcluded: map[string]int{},
+		},
+		{
+			name:             "selected languages",
+			langs:            []string{"Go", " sql"},
+			expectedPaths:    []string{"/root/main.go", "/root/schema.sql"},
+			expectedExcluded: map[string]int{"json": 1, "yaml": 1, unknownLanguage: 1},
+		},
+		{
+			name:             "excluded languages",
+			excludeLangs:     []string{"json", "yaml"},
+			expectedPaths:    []string{"/root/LICENSE", "/root/main.go", "/root/schema.sql"},
+			expectedExcluded: map[string]int{"json": 1, "yaml": 1},
+		},
+		{
+			name:             "exclusion wins",
+			langs:            []string{"go", "sql"},
+			excludeLangs:     []string{"sql"},
+			expectedPaths:    []string{"/root/main.go"},
+			expectedExcluded: map[string]int{"json": 1, "yaml": 1, "sql": 1, unknownLanguage: 1},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			b := &Bundler{
+				cfg:          &config.Config{RootDir: "/root"},
+				langs:        languageSet(tc.langs),
+				excludeLangs: languageSet(tc.excludeLangs),
+				langExcluded: make(map[string]int),
+			}
+			got := b.filterLanguages(append([]string(nil), paths...), results)
+			if !reflect.DeepEqual(got, tc.expectedPaths) {
+				t.Errorf("filterLanguages() = %v; want %v", got, tc.expectedPaths)
+			}
+			if !reflect.DeepEqual(b.langExcluded, tc.expectedExcluded) {
+				t.Errorf("filterLanguages() excluded = %v; want %v", b.langExcluded, tc.expectedExcluded)
+			}
+			if len(b.skipped) != len(paths)-len(tc.expectedPaths) {
+				t.Errorf("filterLanguages() skipped %d files; want %d", len(b.skipped), len(paths)-len(tc.expectedPaths))
+			}
+		})
+	}
+}
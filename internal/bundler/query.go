@@ -0,0 +1,51 @@
+package bundler
+
+import (
+	"fmt"
+	"os"
+	"slices"
+
+	"github.com/axseem/dirmd/internal/processor"
+	"github.com/axseem/dirmd/internal/search"
+	"github.com/axseem/dirmd/internal/tokens"
+)
+
+// skipQuery is the reason files not selected for the query are skipped.
+const skipQuery = "not among the best matches for the query"
+
+// selectByQuery ranks paths by relevance to the query, records the scores,
+// and keeps the best files: as many as fit the token budget if there is
+// one, or the configured number of them otherwise.
+func (b *Bundler) selectByQuery(paths []string, results map[string]processor.Result) []string {
+	idx := search.NewIndex()
+	byName := make(map[string]string, len(paths))
+	for _, path := range paths {
+		name := b.relPath(path)
+		byName[name] = path
+		idx.Add(name, string(results[path].Content))
+	}
+
+	used := 0
+	for _, match := range idx.Search(b.cfg.Query) {
+		path := byName[match.Name]
+		if b.cfg.QueryBudget > 0 {
+			cost := tokens.Estimate(results[path].Content)
+			if used+cost > b.cfg.QueryBudget {
+				continue
+			}
+			used += cost
+		} else if b.cfg.QueryTop > 0 && len(b.scores) == b.cfg.QueryTop {
+			break
+		}
+		b.scores[path] = match.Score
+	}
+	fmt.Fprintf(os.Stderr, "- Selected %d files matching the query.\n", len(b.scores))
+
+	return slices.DeleteFunc(paths, func(path string) bool {
+		if _, ok := b.scores[path]; ok {
+			return false
+		}
+		b.skip(path, skipQuery, false)
+		return true
+	})
+}
@@ -0,0 +1,53 @@
+package bundler
+
+import (
+	"reflect"
+	"slices"
+	"testing"
+
+	"github.com/axseem/dirmd/internal/config"
+	"github.com/axseem/dirmd/internal/processor"
+)
+
+func TestSelectByQuery(t *testing.T) {
+	results := map[string]processor.Result{
+		"/root/retry/policy.go": {Content: []byte("type RetryPolicy struct { MaxRetries int }")},
+		"/root/client.go":       {Content: []byte("for i := 0; i < policy.MaxRetries; i++ { send() }")},
+		"/root/main.go":         {Content: []byte("func main() { run() }")},
+	}
+	paths := slices.Sorted(func(yield func(string) bool) {
+		for path := range results {
+			if !yield(path) {
+				return
+			}
+		}
+	})
+
+	testCases := []struct {
+		name     string
+		top      int
+		budget   int
+		expected []string
+	}{
+		{name: "top", top: 1, expected: []string{"/root/retry/policy.go"}},
+		{name: "all matches", expected: []string{"/root/client.go", "/root/retry/policy.go"}},
+		{name: "budget", budget: 30, expected: []string{"/root/retry/policy.go"}},
+		{name: "budget instead of top", top: 1, budget: 40, expected: []string{"/root/client.go", "/root/retry/policy.go"}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			b := &Bundler{
+				cfg:    &config.Config{RootDir: "/root", Query: "retry policy", QueryTop: tc.top, QueryBudget: tc.budget},
+				scores: make(map[string]float64),
+			}
+			got := b.selectByQuery(slices.Clone(paths), results)
+			if !reflect.DeepEqual(got, tc.expected) {
+				t.Errorf("selectByQuery() = %v; want %v", got, tc.expected)
+			}
+			if len(b.scores) != len(tc.expected) || len(b.skipped) != len(paths)-len(tc.expected) {
+				t.Errorf("selectByQuery() scored %d and skipped %d files", len(b.scores), len(b.skipped))
+			}
+		})
+	}
+}
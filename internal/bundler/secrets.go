@@ -0,0 +1,55 @@
+package bundler
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/axseem/dirmd/internal/detect"
+	"github.com/axseem/dirmd/internal/processor"
+	"github.com/axseem/dirmd/internal/secrets"
+)
+
+// secretsScanner is the name findings of the secrets scan are recorded under.
+const secretsScanner = "secrets"
+
+// scanSecrets looks for credentials in content, the decoded content of the
+// file at path, and redacts them if the mode asks for it. It is safe for
+// concurrent use.
+func (b *Bundler) scanSecrets(path string, content []byte, lang string, category detect.Category) ([]processor.Finding, []byte) {
+	// Files of a category that is not included lose their content anyway.
+	if category != "" && b.policies[category] != detect.Include {
+		return nil, content
+	}
+	found := b.secrets.Scan(b.relPath(path), lang, content)
+	findings := make([]processor.Finding, 0, len(found))
+	for _, f := range found {
+		findings = append(findings, processor.Finding{Scanner: secretsScanner, Rule: f.Rule, Line: f.Line})
+	}
+	if b.secretsMode == secrets.ModeRedact {
+		content = secrets.Redact(content, found)
+	}
+	return findings, content
+}
+
+// reportSecrets prints the secrets found in the bundled files. In abort
+// mode, it fails if there are any.
+func (b *Bundler) reportSecrets(sortedPaths []string, results map[string]processor.Result) error {
+	action := "Possible"
+	if b.secretsMode == secrets.ModeRedact {
+		action = "Redacted"
+	}
+	found := 0
+	for _, path := range sortedPaths {
+		for _, f := range results[path].Findings {
+			if f.Scanner != secretsScanner {
+				continue
+			}
+			found++
+			fmt.Fprintf(os.Stderr, "- %s %s in %s:%d\n", action, f.Rule, b.relPath(path), f.Line)
+		}
+	}
+	if found > 0 && b.secretsMode == secrets.ModeAbort {
+		return fmt.Errorf("found %d possible secrets, aborting", found)
+	}
+	return nil
+}
@@ -0,0 +1,176 @@
+package bundler
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"path/filepath"
+
+	"github.com/axseem/dirmd/internal/atomicfile"
+	"github.com/axseem/dirmd/internal/processor"
+	"github.com/axseem/dirmd/internal/tokens"
+)
+
+// stats summarizes a finished bundle.
+type stats struct {
+	Files  int `json:"files"`
+	Bytes  int `json:"bytes"`
+	Tokens int `json:"tokens"`
+	// Savings holds the total saved by each transform, in the order they ran.
+	Savings []processor.Saving `json:"savings,omitempty"`
+	Entries []fileStats        `json:"entries"`
+	// Languages counts the bundled files and those left out by the language
+	// filters by language.
+	Languages map[string]*languageStats `json:"languages"`
+	Skipped   []skippedFile             `json:"skipped,omitempty"`
+	// Warnings lists the problems that were worked around, such as unreadable directories.
+	Warnings []string `json:"warnings,omitempty"`
+}
+
+// fileStats describes a single bundled file.
+type fileStats struct {
+	Path         string `json:"path"`
+	Language     string `json:"language"`
+	Encoding     string `json:"encoding"`
+	Bytes        int    `json:"bytes"`
+	Tokens       int    `json:"tokens"`
+	Truncated    bool   `json:"truncated,omitempty"`
+	OmittedLines int    `json:"omittedLines,omitempty"`
+
+	Findings []processor.Finding `json:"findings,omitempty"`
+}
+
+// languageStats counts the files of a language.
+type languageStats struct {
+	Included int `json:"included"`
+	Excluded int `json:"excluded,omitempty"`
+}
+
+// skippedFile records a file or directory left out of the bundle's content.
+type skippedFile struct {
+	Path   string `json:"path"`
+	Reason string `json:"reason"`
+	// Listed reports whether the entry still appears in the tree.
+	Listed bool `json:"listed,omitempty"`
+}
+
+// newStats gathers statistics about the bundled markdown and the results it was built from.
+func newStats(rootDir string, sortedPaths []string, results map[string]processor.Result, markdown string) *stats {
+	s := &stats{
+		Bytes:     len(markdown),
+		Tokens:    tokens.Estimate([]byte(markdown)),
+		Entries:   []fileStats{},
+		Languages: make(map[string]*languageStats),
+	}
+	index := make(map[string]int)
+	for _, path := range sortedPaths {
+		res, ok := results[path]
+		if !ok || res.ReadError != nil || res.IsBinary {
+			continue
+		}
+		s.Files++
+		relPath, err := filepath.Rel(rootDir, path)
+		if err != nil {
+			relPath = path
+		}
+		s.Entries = append(s.Entries, fileStats{
+			Path:         filepath.ToSlash(relPath),
+			Language:     res.Language,
+			Encoding:     string(res.Encoding),
+			Bytes:        len(res.Content),
+			Tokens:       tokens.Estimate(res.Content),
+			Truncated:    res.Truncated,
+			OmittedLines: res.OmittedLines,
+			Findings:     res.Findings,
+		})
+		s.language(res.Language).Included++
+		for _, saving := range res.Savings {
+			i, ok := index[saving.Transform]
+			if !ok {
+				i = len(s.Savings)
+				index[saving.Transform] = i
+				s.Savings = append(s.Savings, processor.Saving{Transform: saving.Transform})
+			}
+			s.Savings[i].Bytes += saving.Bytes
+			s.Savings[i].Tokens += saving.Tokens
+		}
+	}
+	return s
+}
+
+// print writes a human-readable summary of the statistics to w.
+func (s *stats) print(w io.Writer) {
+	fmt.Fprintf(w, "- Bundled %d files: %d bytes (~%d tokens)\n", s.Files, s.Bytes, s.Tokens)
+	truncated := 0
+	for _, e := range s.Entries {
+		if e.Truncated {
+			truncated++
+		}
+	}
+	if truncated > 0 {
+		fmt.Fprintf(w, "- Truncated %d oversized files.\n", truncated)
+	}
+	if len(s.Skipped) > 0 {
+		fmt.Fprintf(w, "- Left out the content of %d entries:\n", len(s.Skipped))
+		var reasons []string
+		counts := make(map[string]int)
+		listed := make(map[string]int)
+		for _, skipped := range s.Skipped {
+			if counts[skipped.Reason] == 0 {
+				reasons = append(reasons, skipped.Reason)
+			}
+			counts[skipped.Reason]++
+			if skipped.Listed {
+				listed[skipped.Reason]++
+			}
+		}
+		for _, reason := range reasons {
+			fmt.Fprintf(w, "  - %s: %d (%d listed in the tree)\n", reason, counts[reason], listed[reason])
+		}
+	}
+	if len(s.Savings) > 0 {
+		fmt.Fprintln(w, "- Saved by transforms:")
+		for _, saving := range s.Savings {
+			fmt.Fprintf(w, "  - %s: %d bytes (~%d tokens)\n", saving.Transform, saving.Bytes, saving.Tokens)
+		}
+	}
+	printWarnings(w, s.Warnings)
+}
+
+// printWarnings prints the summary of the problems that were worked around.
+func printWarnings(w io.Writer, warnings []string) {
+	if len(warnings) == 0 {
+		return
+	}
+	fmt.Fprintf(w, "- %d warnings:\n", len(warnings))
+	for _, warning := range warnings {
+		fmt.Fprintf(w, "  - %s\n", warning)
+	}
+}
+
+// language returns the counts of lang, adding them if needed.
+func (s *stats) language(lang string) *languageStats {
+	if lang == "" {
+		lang = unknownLanguage
+	}
+	if s.Languages[lang] == nil {
+		s.Languages[lang] = &languageStats{}
+	}
+	return s.Languages[lang]
+}
+
+// addExcludedLanguages adds the counts of files left out by language.
+func (s *stats) addExcludedLanguages(excluded map[string]int) {
+	for lang, n := range excluded {
+		s.language(lang).Excluded += n
+	}
+}
+
+// writeJSON writes the statistics as an indented JSON document to path.
+func (s *stats) writeJSON(path string) error {
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return err
+	}
+	return atomicfile.WriteFile(path, append(data, '\n'), 0644)
+}
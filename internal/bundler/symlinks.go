@@ -0,0 +1,116 @@
+package bundler
+
+import (
+	"context"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/axseem/dirmd/internal/processor"
+)
+
+// symlinkPolicy decides what happens to symbolic links found in the tree.
+type symlinkPolicy string
+
+const (
+	// symlinksSkip leaves symlinks out entirely.
+	symlinksSkip symlinkPolicy = "skip"
+	// symlinksFollow bundles what symlinks outside the root point to, as if
+	// it were at the link's place.
+	symlinksFollow symlinkPolicy = "follow"
+	// symlinksRecord lists symlinks in the tree with their target, without content.
+	symlinksRecord symlinkPolicy = "record"
+)
+
+// Reasons symlinks are skipped, as reported in the summary.
+const (
+	skipSymlink        = "symlink"
+	skipSymlinkOutside = "symlink outside the root"
+	skipSymlinkLoop    = "symlink loop"
+	skipSymlinkAlias   = "symlink within the root"
+)
+
+// parseSymlinkPolicy validates the name of a symlink policy.
+func parseSymlinkPolicy(name string) (symlinkPolicy, error) {
+	switch p := symlinkPolicy(name); p {
+	case symlinksSkip, symlinksFollow, symlinksRecord:
+		return p, nil
+	}
+	return "", fmt.Errorf("unknown symlink policy %q (want %s, %s or %s)", name, symlinksSkip, symlinksFollow, symlinksRecord)
+}
+
+// visitSymlink handles the symlink at path according to the symlink policy,
+// adding the files to bundle to files. Links to targets within the root are
+// listed with their target instead of followed, as the walk reaches the
+// target under its own path.
+func (b *Bundler) visitSymlink(ctx context.Context, path string, files *[]string) error {
+	switch b.symlinks {
+	case symlinksSkip:
+		b.skip(path, skipSymlink, false)
+		return nil
+	case symlinksRecord:
+		return b.recordSymlink(path, skipSymlink)
+	}
+
+	target, err := filepath.EvalSymlinks(path)
+	if err != nil {
+		b.warn("could not follow symlink %s: %v", b.relPath(path), err)
+		return nil
+	}
+	if isWithin(b.realRoot, target) {
+		return b.recordSymlink(path, skipSymlinkAlias)
+	}
+	if !b.cfg.SymlinksOutside {
+		b.skip(path, skipSymlinkOutside, false)
+		return nil
+	}
+	info, err := os.Stat(target)
+	if err != nil {
+		b.warn("could not follow symlink %s: %v", b.relPath(path), err)
+		return nil
+	}
+	if kind := processor.SpecialFileKind(info.Mode()); kind != "" {
+		b.skip(path, kind, false)
+		return nil
+	}
+	if !info.IsDir() {
+		stat := func() (fs.FileInfo, error) { return info, nil }
+		if b.isIncluded(path) && b.passesFilters(path, stat) && !b.applyPathPolicy(path, b.relPath(path)) {
+			*files = append(*files, path)
+		}
+		return nil
+	}
+
+	// A directory containing the link would be walked forever, and one
+	// that was already followed would be walked again for every link to it.
+	linkDir, err := filepath.EvalSymlinks(filepath.Dir(path))
+	if err != nil {
+		return err
+	}
+	if b.followed[target] || isWithin(target, linkDir) {
+		b.skip(path, skipSymlinkLoop, false)
+		return nil
+	}
+	b.followed[target] = true
+	return b.walk(ctx, target, path, files)
+}
+
+// recordSymlink lists the symlink at path in the tree with its target,
+// skipping it for reason.
+func (b *Bundler) recordSymlink(path, reason string) error {
+	target, err := os.Readlink(path)
+	if err != nil {
+		b.warn("could not read symlink %s: %v", b.relPath(path), err)
+		return nil
+	}
+	b.links[path] = filepath.ToSlash(target)
+	b.skip(path, reason, true)
+	return nil
+}
+
+// isWithin reports whether path is dir or below it. Both must be clean.
+func isWithin(dir, path string) bool {
+	return path == dir || strings.HasPrefix(path, dir+string(filepath.Separator))
+}
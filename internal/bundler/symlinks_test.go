@@ -0,0 +1,152 @@
+//go:build unix
+
+package bundler
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"syscall"
+	"testing"
+
+	"github.com/axseem/dirmd/internal/config"
+)
+
+func TestVisitSymlink(t *testing.T) {
+	testCases := []struct {
+		name            string
+		setup           func(t *testing.T, root, outside string)
+		symlinksOutside bool
+		expectedFiles   []string
+		expectedSkipped []skippedFile
+		expectedLinks   map[string]string
+	}{
+		{
+			name: "link escaping the root",
+			setup: func(t *testing.T, root, outside string) {
+				writeTestFile(t, filepath.Join(outside, "secret.txt"))
+				symlink(t, outside, filepath.Join(root, "ext"))
+			},
+			expectedFiles:   []string{"main.go"},
+			expectedSkipped: []skippedFile{{Path: "ext", Reason: skipSymlinkOutside}},
+		},
+		{
+			name: "followed link outside the root",
+			setup: func(t *testing.T, root, outside string) {
+				writeTestFile(t, filepath.Join(outside, "lib.go"))
+				symlink(t, outside, filepath.Join(root, "ext"))
+			},
+			symlinksOutside: true,
+			expectedFiles:   []string{"ext/lib.go", "main.go"},
+		},
+		{
+			name: "link loop",
+			setup: func(t *testing.T, root, outside string) {
+				symlink(t, filepath.Dir(root), filepath.Join(root, "up"))
+			},
+			symlinksOutside: true,
+			expectedFiles:   []string{"main.go"},
+			expectedSkipped: []skippedFile{{Path: "up", Reason: skipSymlinkLoop}},
+		},
+		{
+			name: "link to a special file",
+			setup: func(t *testing.T, root, outside string) {
+				mkfifo(t, filepath.Join(outside, "fifo"))
+				symlink(t, filepath.Join(outside, "fifo"), filepath.Join(root, "pipe"))
+			},
+			symlinksOutside: true,
+			expectedFiles:   []string{"main.go"},
+			expectedSkipped: []skippedFile{{Path: "pipe", Reason: "named pipe"}},
+		},
+		{
+			name: "link to a special file within the root",
+			setup: func(t *testing.T, root, outside string) {
+				mkfifo(t, filepath.Join(root, "fifo"))
+				symlink(t, "fifo", filepath.Join(root, "pipe"))
+			},
+			expectedFiles: []string{"main.go"},
+			expectedSkipped: []skippedFile{
+				{Path: "fifo", Reason: "named pipe"},
+				{Path: "pipe", Reason: skipSymlinkAlias, Listed: true},
+			},
+			expectedLinks: map[string]string{"pipe": "fifo"},
+		},
+		{
+			name: "directory link within the root",
+			setup: func(t *testing.T, root, outside string) {
+				writeTestFile(t, filepath.Join(root, "pkg", "a.go"))
+				symlink(t, "pkg", filepath.Join(root, "alias"))
+			},
+			expectedFiles:   []string{"main.go", "pkg/a.go"},
+			expectedSkipped: []skippedFile{{Path: "alias", Reason: skipSymlinkAlias, Listed: true}},
+			expectedLinks:   map[string]string{"alias": "pkg"},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			root := filepath.Join(t.TempDir(), "root")
+			outside := t.TempDir()
+			writeTestFile(t, filepath.Join(root, "main.go"))
+			tc.setup(t, root, outside)
+
+			cfg := config.NewDefaultConfig()
+			cfg.RootDir = root
+			cfg.OutputPath = ""
+			cfg.SymlinksOutside = tc.symlinksOutside
+			b, err := New(cfg)
+			if err != nil {
+				t.Fatalf("New() error = %v", err)
+			}
+			paths, err := b.collectFiles(context.Background())
+			if err != nil {
+				t.Fatalf("collectFiles() error = %v", err)
+			}
+
+			var files []string
+			for _, path := range paths {
+				files = append(files, b.relPath(path))
+			}
+			if !reflect.DeepEqual(files, tc.expectedFiles) {
+				t.Errorf("collectFiles() = %v; want %v", files, tc.expectedFiles)
+			}
+			if !reflect.DeepEqual(b.skipped, tc.expectedSkipped) {
+				t.Errorf("skipped = %+v; want %+v", b.skipped, tc.expectedSkipped)
+			}
+			links := make(map[string]string)
+			for path, target := range b.links {
+				links[b.relPath(path)] = target
+			}
+			if len(links) > 0 || len(tc.expectedLinks) > 0 {
+				if !reflect.DeepEqual(links, tc.expectedLinks) {
+					t.Errorf("links = %v; want %v", links, tc.expectedLinks)
+				}
+			}
+		})
+	}
+}
+
+func writeTestFile(t *testing.T, path string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte("package x\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func symlink(t *testing.T, target, link string) {
+	t.Helper()
+	if err := os.Symlink(target, link); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func mkfifo(t *testing.T, path string) {
+	t.Helper()
+	if err := syscall.Mkfifo(path, 0o644); err != nil {
+		t.Skipf("cannot create a named pipe: %v", err)
+	}
+}
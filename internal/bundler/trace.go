@@ -0,0 +1,66 @@
+package bundler
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+
+	"github.com/axseem/dirmd/internal/trace"
+)
+
+// readInput reads the file at path, or standard input for "-". It gives up
+// with the error of ctx once ctx is cancelled, as the input may be a pipe
+// that is never closed.
+func readInput(ctx context.Context, path string) ([]byte, error) {
+	type read struct {
+		data []byte
+		err  error
+	}
+	// The channel is buffered so that a read that outlives ctx does not
+	// block forever once it ends.
+	done := make(chan read, 1)
+	go func() {
+		var r read
+		if path == "-" {
+			r.data, r.err = io.ReadAll(os.Stdin)
+		} else {
+			r.data, r.err = os.ReadFile(path)
+		}
+		done <- r
+	}()
+	select {
+	case r := <-done:
+		return r.data, r.err
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// readTrace reads the stack trace or compiler output at path, or standard
+// input for "-", and returns the lines it refers to by file under root.
+func readTrace(ctx context.Context, path, root string) (map[string][]int, error) {
+	data, err := readInput(ctx, path)
+	if err != nil {
+		return nil, fmt.Errorf("cannot read trace: %w", err)
+	}
+
+	lines := make(map[string][]int)
+	outside := 0
+	for _, ref := range trace.Parse(string(data)) {
+		file, ok := trace.Locate(root, ref.Path)
+		if !ok {
+			outside++
+			continue
+		}
+		if !slices.Contains(lines[file], ref.Line) {
+			lines[file] = append(lines[file], ref.Line)
+		}
+	}
+	if len(lines) == 0 {
+		return nil, fmt.Errorf("the trace refers to no file under %s", root)
+	}
+	fmt.Fprintf(os.Stderr, "- Found %d files referenced by the trace, ignored %d references to other files.\n", len(lines), outside)
+	return lines, nil
+}
@@ -0,0 +1,29 @@
+//go:build unix
+
+package bundler
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"syscall"
+	"testing"
+	"time"
+)
+
+func TestReadInputStopsWhenCancelled(t *testing.T) {
+	fifo := filepath.Join(t.TempDir(), "trace")
+	if err := syscall.Mkfifo(fifo, 0o644); err != nil {
+		t.Skipf("cannot create a named pipe: %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	if _, err := readInput(ctx, fifo); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("readInput() error = %v; want %v", err, context.DeadlineExceeded)
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("readInput() took %v; want it to return once ctx is done", elapsed)
+	}
+}
@@ -0,0 +1,37 @@
+package bundler
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/axseem/dirmd/internal/processor"
+	"github.com/axseem/dirmd/internal/unicodescan"
+)
+
+// reportUnicode prints the suspicious Unicode characters found in the
+// bundled files. In fail mode, it fails if there are any.
+func (b *Bundler) reportUnicode(sortedPaths []string, results map[string]processor.Result) error {
+	action := "Suspicious"
+	if b.opts.UnicodeCheck == unicodescan.ModeEscape {
+		action = "Escaped"
+	}
+	found := 0
+	for _, path := range sortedPaths {
+		for _, f := range results[path].Findings {
+			if f.Scanner != processor.UnicodeScanner {
+				continue
+			}
+			found++
+			if f.Rule == unicodescan.RuleHomoglyph {
+				// Identifiers are left as they are in every mode.
+				fmt.Fprintf(os.Stderr, "- Suspicious %s %q in %s:%d\n", f.Rule, f.Text, b.relPath(path), f.Line)
+				continue
+			}
+			fmt.Fprintf(os.Stderr, "- %s %s %s in %s:%d\n", action, f.Rule, f.Text, b.relPath(path), f.Line)
+		}
+	}
+	if found > 0 && b.opts.UnicodeCheck == unicodescan.ModeFail {
+		return fmt.Errorf("found %d suspicious Unicode characters, failing", found)
+	}
+	return nil
+}
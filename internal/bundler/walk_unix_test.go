@@ -0,0 +1,52 @@
+//go:build unix
+
+package bundler
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/axseem/dirmd/internal/config"
+)
+
+func TestCollectFilesUnreadableDirectory(t *testing.T) {
+	if os.Geteuid() == 0 {
+		t.Skip("permissions do not apply to root")
+	}
+	root := t.TempDir()
+	writeTestFile(t, filepath.Join(root, "main.go"))
+	locked := filepath.Join(root, "locked")
+	writeTestFile(t, filepath.Join(locked, "hidden.go"))
+	if err := os.Chmod(locked, 0); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.Chmod(locked, 0o755) })
+
+	for _, strict := range []bool{false, true} {
+		cfg := config.NewDefaultConfig()
+		cfg.RootDir = root
+		cfg.OutputPath = ""
+		cfg.Strict = strict
+		b, err := New(cfg)
+		if err != nil {
+			t.Fatalf("New() error = %v", err)
+		}
+		files, err := b.collectFiles(context.Background())
+		if strict {
+			if err == nil || !strings.Contains(err.Error(), locked) {
+				t.Errorf("collectFiles() in strict mode error = %v; want one naming %s", err, locked)
+			}
+			continue
+		}
+		if expected := []string{filepath.Join(root, "main.go")}; err != nil || !reflect.DeepEqual(files, expected) {
+			t.Errorf("collectFiles() = %v, %v; want %v", files, err, expected)
+		}
+		if len(b.warnings) != 1 || !strings.Contains(b.warnings[0], "locked") {
+			t.Errorf("collectFiles() warnings = %q; want one naming the directory", b.warnings)
+		}
+	}
+}
@@ -0,0 +1,221 @@
+// Package charset recognises binary files and the text encoding of the
+// others, and converts text to UTF-8.
+package charset
+
+import (
+	"bytes"
+	"fmt"
+	"unicode"
+	"unicode/utf8"
+
+	"golang.org/x/text/encoding"
+	"golang.org/x/text/encoding/charmap"
+	"golang.org/x/text/encoding/japanese"
+	"golang.org/x/text/encoding/unicode/utf32"
+
+	xunicode "golang.org/x/text/encoding/unicode"
+)
+
+// Encoding names what content is encoded as.
+type Encoding string
+
+const (
+	Binary      Encoding = "binary"
+	UTF8        Encoding = "utf-8"
+	UTF8BOM     Encoding = "utf-8-bom"
+	UTF16LE     Encoding = "utf-16le"
+	UTF16BE     Encoding = "utf-16be"
+	UTF32LE     Encoding = "utf-32le"
+	UTF32BE     Encoding = "utf-32be"
+	ShiftJIS    Encoding = "shift_jis"
+	Windows1252 Encoding = "windows-1252"
+)
+
+// SniffLen is how much of the content is looked at to detect its encoding.
+const SniffLen = 8 << 10
+
+// Largest shares of bytes of a kind that text can contain.
+const (
+	// maxControlShare is for control characters.
+	maxControlShare = 0.1
+	// maxBrokenUTF8Share is for invalid bytes in otherwise UTF-8 text.
+	maxBrokenUTF8Share = 0.01
+	// maxLegacyShare is for bytes that are not valid UTF-8, in text in a
+	// legacy encoding.
+	maxLegacyShare = 0.3
+)
+
+// magicNumbers are the leading bytes of common binary formats that can
+// otherwise pass for text.
+var magicNumbers = [][]byte{
+	[]byte("\x89PNG\r\n\x1a\n"),
+	[]byte("\xff\xd8\xff"),     // JPEG
+	[]byte("GIF87a"),           // GIF
+	[]byte("GIF89a"),           // GIF
+	[]byte("%PDF-"),            // PDF
+	[]byte("PK\x03\x04"),       // ZIP, JAR, DOCX, ...
+	[]byte("\x1f\x8b"),         // gzip
+	[]byte("\x7fELF"),          // ELF
+	[]byte("\xca\xfe\xba\xbe"), // Java class, Mach-O fat binary
+	[]byte("\xcf\xfa\xed\xfe"), // Mach-O
+	[]byte("\xce\xfa\xed\xfe"), // Mach-O
+	[]byte("MZ\x90\x00"),       // Windows executable
+	[]byte("\x00asm"),          // WebAssembly
+	[]byte("SQLite format 3\x00"),
+	[]byte("7z\xbc\xaf\x27\x1c"),
+	[]byte("Rar!\x1a\x07"),
+	[]byte("\xfd7zXZ\x00"),     // xz
+	[]byte("\x28\xb5\x2f\xfd"), // zstd
+	[]byte("wOFF"),             // WOFF font
+	[]byte("wOF2"),             // WOFF2 font
+	[]byte("OggS"),
+	[]byte("fLaC"),
+}
+
+// Detect returns the encoding of content, or Binary if it is not text.
+func Detect(content []byte) Encoding {
+	switch {
+	case bytes.HasPrefix(content, []byte("\xef\xbb\xbf")):
+		return UTF8BOM
+	case bytes.HasPrefix(content, []byte("\xff\xfe\x00\x00")):
+		return UTF32LE
+	case bytes.HasPrefix(content, []byte("\x00\x00\xfe\xff")):
+		return UTF32BE
+	case bytes.HasPrefix(content, []byte("\xff\xfe")):
+		return UTF16LE
+	case bytes.HasPrefix(content, []byte("\xfe\xff")):
+		return UTF16BE
+	}
+	for _, magic := range magicNumbers {
+		if bytes.HasPrefix(content, magic) {
+			return Binary
+		}
+	}
+
+	sample := content
+	if len(sample) > SniffLen {
+		sample = sample[:SniffLen]
+	}
+	if enc := detectWide(sample); enc != "" {
+		return enc
+	}
+	if bytes.IndexByte(sample, 0) >= 0 || controlShare(sample) > maxControlShare {
+		return Binary
+	}
+	invalid, multibyte := scanUTF8(sample, len(sample) < len(content))
+	share := float64(invalid) / float64(len(sample))
+	switch {
+	case invalid == 0, multibyte > 0 && share <= maxBrokenUTF8Share:
+		return UTF8
+	case looksLikeShiftJIS(sample):
+		return ShiftJIS
+	case share > maxLegacyShare:
+		return Binary
+	}
+	return Windows1252
+}
+
+// Decode converts content in the given encoding to UTF-8, dropping any byte
+// order mark.
+func Decode(content []byte, enc Encoding) ([]byte, error) {
+	var e encoding.Encoding
+	switch enc {
+	case UTF8:
+		return bytes.ToValidUTF8(content, []byte(string(utf8.RuneError))), nil
+	case UTF8BOM:
+		return bytes.TrimPrefix(content, []byte("\xef\xbb\xbf")), nil
+	case UTF16LE:
+		e = xunicode.UTF16(xunicode.LittleEndian, xunicode.UseBOM)
+	case UTF16BE:
+		e = xunicode.UTF16(xunicode.BigEndian, xunicode.UseBOM)
+	case UTF32LE:
+		e = utf32.UTF32(utf32.LittleEndian, utf32.UseBOM)
+	case UTF32BE:
+		e = utf32.UTF32(utf32.BigEndian, utf32.UseBOM)
+	case ShiftJIS:
+		e = japanese.ShiftJIS
+	case Windows1252:
+		e = charmap.Windows1252
+	default:
+		return nil, fmt.Errorf("cannot decode %s content", enc)
+	}
+	return e.NewDecoder().Bytes(content)
+}
+
+// detectWide recognises UTF-16 and UTF-32 without a byte order mark by
+// where the zero bytes of mostly ASCII text fall.
+func detectWide(sample []byte) Encoding {
+	if len(sample) < 4 {
+		return ""
+	}
+	var zeros [4]int
+	for i, c := range sample {
+		if c == 0 {
+			zeros[i%4]++
+		}
+	}
+	quarter := len(sample) / 4
+	mostly := func(n int) bool { return n > quarter*9/10 }
+	rarely := func(n int) bool { return n < quarter/10+1 }
+	switch {
+	case rarely(zeros[0]) && mostly(zeros[1]) && mostly(zeros[2]) && mostly(zeros[3]):
+		return UTF32LE
+	case mostly(zeros[0]) && mostly(zeros[1]) && mostly(zeros[2]) && rarely(zeros[3]):
+		return UTF32BE
+	case rarely(zeros[0]) && rarely(zeros[2]) && mostly(zeros[1]) && mostly(zeros[3]):
+		return UTF16LE
+	case mostly(zeros[0]) && mostly(zeros[2]) && rarely(zeros[1]) && rarely(zeros[3]):
+		return UTF16BE
+	}
+	return ""
+}
+
+// controlShare is the share of bytes in sample that are control characters
+// other than the ones common in text.
+func controlShare(sample []byte) float64 {
+	if len(sample) == 0 {
+		return 0
+	}
+	n := 0
+	for _, c := range sample {
+		if c < 0x20 && c != '\t' && c != '\n' && c != '\r' && c != '\f' && c != '\b' && c != 0x1b {
+			n++
+		}
+	}
+	return float64(n) / float64(len(sample))
+}
+
+// scanUTF8 counts the bytes of sample that are not valid UTF-8 and the
+// valid multibyte runes. If the sample was cut from longer content, a rune
+// split at its end is not counted as invalid.
+func scanUTF8(sample []byte, cut bool) (invalid, multibyte int) {
+	for i := 0; i < len(sample); {
+		r, size := utf8.DecodeRune(sample[i:])
+		switch {
+		case r == utf8.RuneError && size == 1:
+			if cut && !utf8.FullRune(sample[i:]) {
+				return invalid, multibyte
+			}
+			invalid++
+		case size > 1:
+			multibyte++
+		}
+		i += size
+	}
+	return invalid, multibyte
+}
+
+// looksLikeShiftJIS reports whether sample decodes cleanly as Shift JIS
+// and contains kana, which single-byte encodings would rarely produce.
+func looksLikeShiftJIS(sample []byte) bool {
+	decoded, err := japanese.ShiftJIS.NewDecoder().Bytes(sample)
+	if err != nil || bytes.ContainsRune(decoded, utf8.RuneError) {
+		return false
+	}
+	for _, r := range string(decoded) {
+		if unicode.In(r, unicode.Hiragana, unicode.Katakana) {
+			return true
+		}
+	}
+	return false
+}
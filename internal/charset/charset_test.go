@@ -0,0 +1,127 @@
+package charset
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestDetect(t *testing.T) {
+	testCases := []struct {
+		name     string
+		data     []byte
+		expected Encoding
+	}{
+		{
+			name:     "Simple text",
+			data:     []byte("hello world"),
+			expected: UTF8,
+		},
+		{
+			name:     "UTF-8 text",
+			data:     []byte("こんにちは"),
+			expected: UTF8,
+		},
+		{
+			name:     "Text with newline and tab",
+			data:     []byte("line 1\n\tline 2"),
+			expected: UTF8,
+		},
+		{
+			name:     "Empty data",
+			data:     []byte{},
+			expected: UTF8,
+		},
+		{
+			name:     "Data with null byte",
+			data:     []byte("this is a \x00 binary"),
+			expected: Binary,
+		},
+		{
+			name:     "Image file header (PNG)",
+			data:     []byte{0x89, 0x50, 0x4E, 0x47, 0x0D, 0x0A, 0x1A, 0x0A},
+			expected: Binary,
+		},
+		{
+			name:     "ELF executable header",
+			data:     []byte{0x7f, 'E', 'L', 'F', 0x02, 0x01, 0x01, 0x00},
+			expected: Binary,
+		},
+		{
+			name:     "Control characters",
+			data:     []byte("\x01\x02\x03\x04abc\x05\x06"),
+			expected: Binary,
+		},
+		{
+			name:     "UTF-8 with BOM",
+			data:     []byte("\xef\xbb\xbfpackage main\n"),
+			expected: UTF8BOM,
+		},
+		{
+			name:     "UTF-16LE with BOM",
+			data:     []byte("\xff\xfeh\x00i\x00\n\x00"),
+			expected: UTF16LE,
+		},
+		{
+			name:     "UTF-16BE without BOM",
+			data:     []byte("\x00h\x00e\x00l\x00l\x00o\x00\n"),
+			expected: UTF16BE,
+		},
+		{
+			name:     "UTF-32LE without BOM",
+			data:     []byte("h\x00\x00\x00i\x00\x00\x00\n\x00\x00\x00"),
+			expected: UTF32LE,
+		},
+		{
+			name:     "UTF-8 with a stray invalid byte",
+			data:     append([]byte("// héllo wörld, "+string(bytes.Repeat([]byte("x"), 200))), 0xff),
+			expected: UTF8,
+		},
+		{
+			name:     "Latin-1",
+			data:     []byte("caf\xe9 r\xe9sum\xe9\n"),
+			expected: Windows1252,
+		},
+		{
+			name:     "Shift JIS",
+			data:     []byte("// \x82\xb1\x82\xf1\x82\xc9\x82\xbf\x82\xcd\n"),
+			expected: ShiftJIS,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := Detect(tc.data); got != tc.expected {
+				t.Errorf("Detect() = %v; want %v", got, tc.expected)
+			}
+		})
+	}
+}
+
+func TestDecode(t *testing.T) {
+	testCases := []struct {
+		name     string
+		data     []byte
+		enc      Encoding
+		expected string
+	}{
+		{name: "UTF-8 BOM is dropped", data: []byte("\xef\xbb\xbfa"), enc: UTF8BOM, expected: "a"},
+		{name: "UTF-16LE", data: []byte("\xff\xfeh\x00i\x00"), enc: UTF16LE, expected: "hi"},
+		{name: "UTF-16BE", data: []byte("\x00h\x00i"), enc: UTF16BE, expected: "hi"},
+		{name: "UTF-32BE", data: []byte("\x00\x00\x00h"), enc: UTF32BE, expected: "h"},
+		{name: "Windows-1252", data: []byte("caf\xe9 \x80"), enc: Windows1252, expected: "café €"},
+		{name: "Shift JIS", data: []byte("\x82\xb1\x82\xf1"), enc: ShiftJIS, expected: "こん"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := Decode(tc.data, tc.enc)
+			if err != nil || string(got) != tc.expected {
+				t.Errorf("Decode() = %q, %v; want %q", got, err, tc.expected)
+			}
+		})
+	}
+
+	if _, err := Decode([]byte{0}, Binary); err == nil {
+		t.Error("Decode() of binary content succeeded; want an error")
+	}
+}
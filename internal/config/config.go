@@ -1,6 +1,14 @@
 package config
 
-import "runtime"
+import (
+	"runtime"
+	"time"
+
+	"github.com/axseem/dirmd/internal/detect"
+	"github.com/axseem/dirmd/internal/secrets"
+	"github.com/axseem/dirmd/internal/transform"
+	"github.com/axseem/dirmd/internal/unicodescan"
+)
 
 // Config holds all the configuration for the dirmd tool.
 type Config struct {
@@ -12,15 +20,146 @@ type Config struct {
 	IgnoreFilePath string
 	// Workers is the number of concurrent workers to use for file processing.
 	Workers int
+	// Include holds .gitignore-style patterns. If any are given, only files
+	// matching one of them are bundled.
+	Include []string
+	// Exclude holds .gitignore-style patterns of files and directories to leave out,
+	// in addition to the ignore files.
+	Exclude []string
+	// MaxReadBytes is the size above which files are skipped. Zero means no limit.
+	MaxReadBytes int64
+	// ReadTimeout is how long reading a single file may take. Zero means no limit.
+	ReadTimeout time.Duration
+	// Strict fails the run on the first unreadable file or directory instead
+	// of skipping it with a warning.
+	Strict bool
+	// Symlinks decides whether symlinks are skipped, followed or listed with
+	// their target. Followed links to targets within the root are listed too,
+	// as their target is bundled under its own path.
+	Symlinks string
+	// SymlinksOutside allows following symlinks to targets outside the root directory.
+	SymlinksOutside bool
 	// IncludeHidden specifies whether to include hidden files and directories.
 	IncludeHidden bool
+	// Languages maps file names, such as Jenkinsfile, and extensions, such as
+	// .tmpl, to the language of the files, overriding the built-in mappings.
+	// The mappings of configuration files and environment variables are
+	// merged, unless the flag is given.
+	Languages map[string]string
+	// MaxDepth is the number of directory levels to bundle files from, the
+	// root being the first. Zero means no limit.
+	MaxDepth int
+	// ExcludeLargerThan is the size in kilobytes above which files are left out. Zero means no limit.
+	ExcludeLargerThan int
+	// ExcludeLongerThan is the number of lines above which files are left out. Zero means no limit.
+	ExcludeLongerThan int
+	// NewerThan leaves out the files not modified after a date or within a
+	// duration, such as 7d. Empty means no limit.
+	NewerThan string
+	// Langs holds the languages of the files to bundle. Empty means all of them.
+	Langs []string
+	// ExcludeLangs holds the languages of files to leave out.
+	ExcludeLangs []string
+	// StripComments specifies whether to remove comments from file contents.
+	StripComments bool
+	// KeepDocComments specifies whether documentation comments survive comment stripping.
+	KeepDocComments bool
+	// NormalizeNewlines specifies whether to convert CRLF line endings to LF.
+	NormalizeNewlines bool
+	// TrimTrailingWhitespace specifies whether to remove blanks at the end of lines.
+	TrimTrailingWhitespace bool
+	// CollapseBlankLines specifies whether to replace runs of blank lines with a single one.
+	CollapseBlankLines bool
+	// IndentWithTabs specifies whether to rewrite leading spaces as tabs.
+	IndentWithTabs bool
+	// TabWidth is the number of columns one level of indentation spans.
+	TabWidth int
+	// LineNumbers specifies whether to prefix every line of file contents with its line number in the file.
+	LineNumbers bool
+	// LineNumberFormat is the line number prefix, with {n} standing for the number.
+	LineNumberFormat string
+	// LineNumbersRelative specifies whether lines kept from a truncated file
+	// are numbered from 1 instead of by their position in the file.
+	LineNumbersRelative bool
+	// MaxFileLines is the number of lines above which a file is truncated. Zero means no limit.
+	MaxFileLines int
+	// MaxFileBytes is the size in bytes above which a file is truncated. Zero means no limit.
+	MaxFileBytes int
+	// TruncateStrategy selects which lines of an oversized file are kept.
+	TruncateStrategy string
+	// TruncateMatch is the pattern of extra lines kept by the head-tail-match strategy.
+	TruncateMatch string
+	// Grep is the pattern file contents must match to be bundled. Empty means all files.
+	Grep string
+	// GrepContext is the number of lines kept around the lines Grep matches,
+	// leaving out the others. Negative values keep whole files.
+	GrepContext int
+	// FromTrace is the path of a stack trace or compiler output, or "-" for
+	// standard input. Only the files it refers to are bundled, with the
+	// referenced lines marked.
+	FromTrace string
+	// TraceContext is the number of lines kept around the lines FromTrace
+	// refers to, leaving out the others. Negative values keep whole files.
+	TraceContext int
+	// Coverage is the path of a Go coverage profile. Only the files with
+	// code it reports as not covered are bundled, with those lines marked.
+	Coverage string
+	// CoverageTests also bundles the test files of the packages of the files
+	// selected by Coverage.
+	CoverageTests bool
+	// Query selects the files most relevant to it, ranked with BM25 over
+	// their paths and contents. Empty means all files.
+	Query string
+	// QueryTop is the number of files Query selects, unless QueryBudget is
+	// set. Zero means all matching files.
+	QueryTop int
+	// QueryBudget is the estimated number of tokens of the file contents
+	// Query selects. Zero means QueryTop applies instead.
+	QueryBudget int
+	// ReportPath is the path of a JSON report about the bundle. Empty means no report.
+	ReportPath string
+	// GeneratedPolicy decides whether generated files are included, excluded or only listed.
+	GeneratedPolicy string
+	// VendoredPolicy decides whether vendored files are included, excluded or only listed.
+	VendoredPolicy string
+	// MinifiedPolicy decides whether minified files are included, excluded or only listed.
+	MinifiedPolicy string
+	// SecretsMode decides whether found secrets are ignored, reported, redacted or abort the run.
+	SecretsMode string
+	// SecretsAllowlistPath is the path to a file of secrets and paths that are never reported.
+	SecretsAllowlistPath string
+	// UnicodeCheck decides whether suspicious Unicode characters are ignored,
+	// reported, escaped or fail the run.
+	UnicodeCheck string
+	// MaskValues holds .gitignore-style patterns of configuration files whose
+	// values are replaced with placeholders describing their type.
+	MaskValues []string
+	// AnonymizeRulesPath is the path to a file of terms and patterns to anonymize. Empty disables anonymization.
+	AnonymizeRulesPath string
+	// AnonymizeMapPath is the path of the private file mapping placeholders back to the original text.
+	AnonymizeMapPath string
 }
 
 // NewDefaultConfig creates a new configuration with default values.
 func NewDefaultConfig() *Config {
 	return &Config{
-		OutputPath:    "bundle.md",
-		Workers:       runtime.NumCPU(),
-		IncludeHidden: false,
+		OutputPath:       "bundle.md",
+		Workers:          runtime.NumCPU(),
+		IncludeHidden:    false,
+		Symlinks:         "follow",
+		MaxReadBytes:     10 << 20,
+		ReadTimeout:      10 * time.Second,
+		TabWidth:         4,
+		LineNumberFormat: transform.DefaultLineNumberFormat,
+		TruncateStrategy: string(transform.TruncateHeadTail),
+		GrepContext:      -1,
+		TraceContext:     -1,
+		QueryTop:         10,
+		GeneratedPolicy:  string(detect.List),
+		VendoredPolicy:   string(detect.List),
+		MinifiedPolicy:   string(detect.List),
+		SecretsMode:      string(secrets.ModeWarn),
+		UnicodeCheck:     string(unicodescan.ModeWarn),
+		AnonymizeMapPath: "dirmd-anonymize-map.json",
 	}
 }
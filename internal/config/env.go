@@ -0,0 +1,94 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/spf13/pflag"
+)
+
+// EnvPrefix starts the names of the environment variables options can be set with.
+const EnvPrefix = "DIRMD_"
+
+// EnvVar returns the name of the environment variable for a flag, such as
+// DIRMD_MAX_FILE_LINES for max-file-lines.
+func EnvVar(flagName string) string {
+	return EnvPrefix + strings.ToUpper(strings.ReplaceAll(flagName, "-", "_"))
+}
+
+// applyEnv sets the flags that have an environment variable, except those
+// set on the command line, and records the variable as their source.
+func applyEnv(flags *pflag.FlagSet, sources Sources) error {
+	var err error
+	flags.VisitAll(func(flag *pflag.Flag) {
+		if err != nil || !isOption(flag) || flag.Changed {
+			return
+		}
+		name := EnvVar(flag.Name)
+		value, ok := os.LookupEnv(name)
+		if !ok {
+			return
+		}
+		if setErr := setFromEnv(flag, value); setErr != nil {
+			err = fmt.Errorf("invalid %s=%q: expected %s", name, value, describeType(flag.Value.Type()))
+			return
+		}
+		sources[flag.Name] = "$" + name
+	})
+	return err
+}
+
+// setFromEnv sets flag to the value of an environment variable. Lists are
+// separated by commas.
+func setFromEnv(flag *pflag.Flag, value string) error {
+	slice, ok := flag.Value.(pflag.SliceValue)
+	if !ok {
+		return flag.Value.Set(value)
+	}
+	return slice.Replace(splitList(value))
+}
+
+// splitList splits a comma-separated list, trimming blanks around the items.
+func splitList(value string) []string {
+	var items []string
+	if value != "" {
+		for item := range strings.SplitSeq(value, ",") {
+			items = append(items, strings.TrimSpace(item))
+		}
+	}
+	return items
+}
+
+// describeType describes the values flags of the given pflag type accept.
+func describeType(typ string) string {
+	switch typ {
+	case "bool":
+		return "true or false"
+	case "int", "int64":
+		return "an integer"
+	case "duration":
+		return "a duration such as 30s or 5m"
+	case "stringSlice":
+		return "a comma-separated list"
+	case "stringToString":
+		return "comma-separated name=value pairs"
+	}
+	return "a " + typ
+}
+
+// EnvHelp lists the environment variables of the options in flags and of
+// the profile selection, for the help of a command.
+func EnvHelp(flags *pflag.FlagSet) string {
+	var b strings.Builder
+	w := tabwriter.NewWriter(&b, 0, 4, 2, ' ', 0)
+	flags.VisitAll(func(flag *pflag.Flag) {
+		if flag.Name == "help" {
+			return
+		}
+		fmt.Fprintf(w, "  %s\t%s\t--%s\n", EnvVar(flag.Name), describeType(flag.Value.Type()), flag.Name)
+	})
+	w.Flush()
+	return b.String()
+}
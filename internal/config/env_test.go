@@ -0,0 +1,76 @@
+package config
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/spf13/pflag"
+)
+
+func TestApplyEnv(t *testing.T) {
+	testCases := []struct {
+		name     string
+		env      map[string]string
+		args     []string
+		expected Config
+		wantErr  bool
+	}{
+		{
+			name: "Typed values",
+			env: map[string]string{
+				"DIRMD_WORKERS":        "3",
+				"DIRMD_STRIP_COMMENTS": "1",
+				"DIRMD_READ_TIMEOUT":   "1m",
+				"DIRMD_EXCLUDE":        "*.log, dist/",
+			},
+			expected: Config{Workers: 3, StripComments: true, ReadTimeout: time.Minute, Exclude: []string{"*.log", "dist/"}},
+		},
+		{
+			name:     "Command line wins",
+			env:      map[string]string{"DIRMD_WORKERS": "3"},
+			args:     []string{"--workers", "5"},
+			expected: Config{Workers: 5},
+		},
+		{
+			name:     "Empty list",
+			env:      map[string]string{"DIRMD_EXCLUDE": ""},
+			expected: Config{Workers: 1},
+		},
+		{
+			name:    "Malformed integer",
+			env:     map[string]string{"DIRMD_WORKERS": "many"},
+			wantErr: true,
+		},
+		{
+			name:    "Malformed duration",
+			env:     map[string]string{"DIRMD_READ_TIMEOUT": "soon"},
+			wantErr: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			for name, value := range tc.env {
+				t.Setenv(name, value)
+			}
+			var cfg Config
+			flags := pflag.NewFlagSet("dirmd", pflag.ContinueOnError)
+			flags.IntVar(&cfg.Workers, "workers", 1, "")
+			flags.BoolVar(&cfg.StripComments, "strip-comments", false, "")
+			flags.DurationVar(&cfg.ReadTimeout, "read-timeout", 0, "")
+			flags.StringSliceVar(&cfg.Exclude, "exclude", nil, "")
+			if err := flags.Parse(tc.args); err != nil {
+				t.Fatal(err)
+			}
+
+			err := applyEnv(flags, make(Sources))
+			if (err != nil) != tc.wantErr {
+				t.Fatalf("applyEnv() error = %v; want error %v", err, tc.wantErr)
+			}
+			if !tc.wantErr && !reflect.DeepEqual(cfg, tc.expected) {
+				t.Errorf("applyEnv() config = %+v; want %+v", cfg, tc.expected)
+			}
+		})
+	}
+}
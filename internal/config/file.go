@@ -0,0 +1,283 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+	"github.com/spf13/pflag"
+	"gopkg.in/yaml.v3"
+)
+
+// projectFileNames are the names of project configuration files, by preference.
+var projectFileNames = []string{".dirmd.toml", "dirmd.toml", ".dirmd.yaml", "dirmd.yaml", ".dirmd.yml", "dirmd.yml"}
+
+// userFileNames are the names of the user configuration file in the dirmd
+// directory of $XDG_CONFIG_HOME, by preference.
+var userFileNames = []string{"config.toml", "config.yaml", "config.yml"}
+
+// SourceCommandLine is the source of options set with flags.
+const SourceCommandLine = "command line"
+
+// ProfileFlag is the name of the flag selecting a profile. Like help, it is
+// not an option configuration files can set.
+const ProfileFlag = "profile"
+
+// isOption reports whether the flag is one configuration files can set.
+func isOption(flag *pflag.Flag) bool {
+	return flag.Name != "help" && flag.Name != ProfileFlag
+}
+
+// File is a configuration file. Its keys are the names of the command-line
+// flags, without the leading dashes.
+type File struct {
+	Path   string
+	Values map[string]any
+	// Profiles holds the profiles defined in the file by name.
+	Profiles map[string]*Profile
+}
+
+// Sources maps the options that were set to where their value came from.
+// Options left at their default are absent.
+type Sources map[string]string
+
+// ReadFile reads a TOML or YAML configuration file, depending on its extension.
+func ReadFile(path string) (*File, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	values := make(map[string]any)
+	switch filepath.Ext(path) {
+	case ".toml":
+		err = toml.Unmarshal(data, &values)
+	case ".yaml", ".yml":
+		err = yaml.Unmarshal(data, &values)
+	default:
+		return nil, fmt.Errorf("%s: unknown configuration format", path)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	profiles, err := readProfiles(path, values[profilesKey])
+	if err != nil {
+		return nil, err
+	}
+	delete(values, profilesKey)
+	return &File{Path: path, Values: values, Profiles: profiles}, nil
+}
+
+// FindProjectFile returns the path of the configuration file in dir or the
+// closest of its parents, or an empty string if there is none.
+func FindProjectFile(dir string) (string, error) {
+	for {
+		path, err := findFile(dir, projectFileNames)
+		if path != "" || err != nil {
+			return path, err
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return "", nil
+		}
+		dir = parent
+	}
+}
+
+// FindUserFile returns the path of the user's configuration file, or an
+// empty string if there is none.
+func FindUserFile() (string, error) {
+	dir := os.Getenv("XDG_CONFIG_HOME")
+	if dir == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return "", nil
+		}
+		dir = filepath.Join(home, ".config")
+	}
+	return findFile(filepath.Join(dir, "dirmd"), userFileNames)
+}
+
+func findFile(dir string, names []string) (string, error) {
+	for _, name := range names {
+		path := filepath.Join(dir, name)
+		info, err := os.Stat(path)
+		if err == nil && !info.IsDir() {
+			return path, nil
+		}
+		if err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return "", err
+		}
+	}
+	return "", nil
+}
+
+// Apply sets the flags named in f to their value in f, except those set on
+// the command line, and records f as their source.
+func (f *File) Apply(flags *pflag.FlagSet, sources Sources) error {
+	keys := make([]string, 0, len(f.Values))
+	for key := range f.Values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		flag := flags.Lookup(key)
+		if flag == nil || !isOption(flag) {
+			return fmt.Errorf("%s: unknown option %q", f.Path, key)
+		}
+		if flag.Changed {
+			continue
+		}
+		if err := setFlag(flag, f.Values[key]); err != nil {
+			return fmt.Errorf("%s: invalid value for %s: %w", f.Path, key, err)
+		}
+		sources[key] = f.Path
+	}
+	return nil
+}
+
+// setFlag sets flag to a value decoded from a configuration file. Lists
+// replace the value of the flag rather than adding to it, so that each
+// layer overrides the ones below.
+func setFlag(flag *pflag.Flag, value any) error {
+	slice, isSlice := flag.Value.(pflag.SliceValue)
+	switch v := value.(type) {
+	case []any:
+		items := make([]string, len(v))
+		for i, item := range v {
+			items[i] = fmt.Sprint(item)
+		}
+		if isSlice {
+			return slice.Replace(items)
+		}
+		return flag.Value.Set(strings.Join(items, ","))
+	case map[string]any:
+		if flag.Value.Type() != "stringToString" {
+			return errors.New("expected a value, not a table")
+		}
+		pairs := make([]string, 0, len(v))
+		for _, key := range slices.Sorted(maps.Keys(v)) {
+			pairs = append(pairs, key+"="+fmt.Sprint(v[key]))
+		}
+		return flag.Value.Set(strings.Join(pairs, ","))
+	}
+	if isSlice {
+		return slice.Replace(splitList(fmt.Sprint(value)))
+	}
+	return flag.Value.Set(fmt.Sprint(value))
+}
+
+// Files are the configuration files that apply to a directory.
+type Files struct {
+	// User is the user's configuration file, if there is one.
+	User *File
+	// Project is the closest project configuration file, if there is one.
+	Project *File
+}
+
+// Discover reads the user configuration file and the project
+// configuration file for dir.
+func Discover(dir string) (*Files, error) {
+	var files Files
+	userPath, err := FindUserFile()
+	if err != nil {
+		return nil, err
+	}
+	if userPath != "" {
+		if files.User, err = ReadFile(userPath); err != nil {
+			return nil, err
+		}
+	}
+	projectPath, err := FindProjectFile(dir)
+	if err != nil {
+		return nil, err
+	}
+	if projectPath != "" {
+		if files.Project, err = ReadFile(projectPath); err != nil {
+			return nil, err
+		}
+	}
+	return &files, nil
+}
+
+// Apply sets flags from the files, the profile, which may be nil, and the
+// environment. Flags set on the command line take precedence over
+// environment variables, then the profile, the project file and the user file.
+func (files *Files) Apply(flags *pflag.FlagSet, profile *Profile) (Sources, error) {
+	sources := make(Sources)
+	flags.Visit(func(flag *pflag.Flag) {
+		sources[flag.Name] = SourceCommandLine
+	})
+
+	layers := []*File{files.User, files.Project}
+	if profile != nil {
+		layers = append(layers, &File{Path: fmt.Sprintf("profile %s in %s", profile.Name, profile.Path), Values: profile.Values})
+	}
+	for _, layer := range layers {
+		if layer == nil {
+			continue
+		}
+		if err := layer.Apply(flags, sources); err != nil {
+			return nil, err
+		}
+	}
+	if err := applyEnv(flags, sources); err != nil {
+		return nil, err
+	}
+	return sources, nil
+}
+
+// Format renders the values of flags as a TOML configuration file, with the
+// source of each value in a comment.
+func Format(flags *pflag.FlagSet, sources Sources) string {
+	var b strings.Builder
+	flags.VisitAll(func(flag *pflag.Flag) {
+		if !isOption(flag) {
+			return
+		}
+		source := sources[flag.Name]
+		if source == "" {
+			source = "default"
+		}
+		fmt.Fprintf(&b, "%s = %s # %s\n", flag.Name, formatValue(flag.Value), source)
+	})
+	return b.String()
+}
+
+// formatValue renders a flag value as a TOML value.
+func formatValue(value pflag.Value) string {
+	switch value.Type() {
+	case "bool", "int", "int64":
+		return value.String()
+	}
+	if value.Type() == "stringToString" {
+		// The value is rendered as "[key=value,...]".
+		var pairs []string
+		for pair := range strings.SplitSeq(strings.Trim(value.String(), "[]"), ",") {
+			if key, v, ok := strings.Cut(pair, "="); ok {
+				pairs = append(pairs, strconv.Quote(key)+" = "+strconv.Quote(v))
+			}
+		}
+		if len(pairs) == 0 {
+			return "{}"
+		}
+		slices.Sort(pairs)
+		return "{ " + strings.Join(pairs, ", ") + " }"
+	}
+	if slice, ok := value.(pflag.SliceValue); ok {
+		items := slices.Clone(slice.GetSlice())
+		for i, item := range items {
+			items[i] = strconv.Quote(item)
+		}
+		return "[" + strings.Join(items, ", ") + "]"
+	}
+	return strconv.Quote(value.String())
+}
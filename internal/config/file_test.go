@@ -0,0 +1,104 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/spf13/pflag"
+)
+
+func TestApply(t *testing.T) {
+	root := t.TempDir()
+	xdg := t.TempDir()
+	t.Setenv("XDG_CONFIG_HOME", xdg)
+
+	userFile := filepath.Join(xdg, "dirmd", "config.toml")
+	projectFile := filepath.Join(root, ".dirmd.yaml")
+	contents := map[string]string{
+		userFile:    "workers = 3\ntab-width = 2\nexclude = [\"*.tmp\"]\n",
+		projectFile: "workers: 5\nstrip-comments: true\nexclude: [\"*.log\", \"dist/\"]\n",
+	}
+	for path, content := range contents {
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	dir := filepath.Join(root, "sub")
+	if err := os.Mkdir(dir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg := &Config{}
+	flags := pflag.NewFlagSet("dirmd", pflag.ContinueOnError)
+	flags.IntVar(&cfg.Workers, "workers", 1, "")
+	flags.IntVar(&cfg.TabWidth, "tab-width", 4, "")
+	flags.BoolVar(&cfg.StripComments, "strip-comments", false, "")
+	flags.StringSliceVar(&cfg.Exclude, "exclude", nil, "")
+	if err := flags.Parse([]string{"--tab-width", "8"}); err != nil {
+		t.Fatal(err)
+	}
+
+	files, err := Discover(dir)
+	if err != nil {
+		t.Fatalf("Discover() error = %v", err)
+	}
+	sources, err := files.Apply(flags, nil)
+	if err != nil {
+		t.Fatalf("Apply() error = %v", err)
+	}
+
+	expected := &Config{Workers: 5, TabWidth: 8, StripComments: true, Exclude: []string{"*.log", "dist/"}}
+	if !reflect.DeepEqual(cfg, expected) {
+		t.Errorf("Apply() config = %+v; want %+v", cfg, expected)
+	}
+	expectedSources := Sources{
+		"workers":        projectFile,
+		"tab-width":      SourceCommandLine,
+		"strip-comments": projectFile,
+		"exclude":        projectFile,
+	}
+	if !reflect.DeepEqual(sources, expectedSources) {
+		t.Errorf("Apply() sources = %v; want %v", sources, expectedSources)
+	}
+
+	files.Project.Values["workers"] = "many"
+	if _, err := files.Apply(flags, nil); err == nil {
+		t.Error("Apply() with an invalid value succeeded; want an error")
+	}
+}
+
+func TestApplyReplacesLists(t *testing.T) {
+	testCases := []struct {
+		name     string
+		user     any
+		project  any
+		expected []string
+	}{
+		{name: "Lists", user: []any{"*.tmp", "build/"}, project: []any{"*.log"}, expected: []string{"*.log"}},
+		{name: "Strings", user: "*.tmp", project: "*.log, dist/", expected: []string{"*.log", "dist/"}},
+		{name: "String over list", user: []any{"*.tmp"}, project: "*.log", expected: []string{"*.log"}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			var exclude []string
+			flags := pflag.NewFlagSet("dirmd", pflag.ContinueOnError)
+			flags.StringSliceVar(&exclude, "exclude", []string{"default/"}, "")
+			files := &Files{
+				User:    &File{Path: "user.toml", Values: map[string]any{"exclude": tc.user}},
+				Project: &File{Path: "project.toml", Values: map[string]any{"exclude": tc.project}},
+			}
+			if _, err := files.Apply(flags, nil); err != nil {
+				t.Fatalf("Apply() error = %v", err)
+			}
+			if !reflect.DeepEqual(exclude, tc.expected) {
+				t.Errorf("Apply() exclude = %q; want %q", exclude, tc.expected)
+			}
+		})
+	}
+}
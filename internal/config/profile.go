@@ -0,0 +1,120 @@
+package config
+
+import (
+	"fmt"
+	"path/filepath"
+)
+
+// profilesKey is the key of the table of profiles in configuration files.
+const profilesKey = "profiles"
+
+// Profile is a named set of options in a configuration file, selected with
+// --profile. Besides options, a profile can have a description, the name of
+// a profile it extends and the directory it bundles.
+type Profile struct {
+	Name        string
+	Description string
+	// Extends names the profile this one inherits options from.
+	Extends string
+	// Root is the directory the profile bundles when none is given. It is
+	// relative to the file the profile is defined in.
+	Root string
+	// Path is the file the profile is defined in.
+	Path string
+	// Values maps the names of options to their value.
+	Values map[string]any
+}
+
+// readProfiles parses the profiles table of the configuration file at path.
+func readProfiles(path string, raw any) (map[string]*Profile, error) {
+	if raw == nil {
+		return nil, nil
+	}
+	table, ok := raw.(map[string]any)
+	if !ok {
+		return nil, fmt.Errorf("%s: %s must be a table", path, profilesKey)
+	}
+
+	profiles := make(map[string]*Profile, len(table))
+	for name, entry := range table {
+		values, ok := entry.(map[string]any)
+		if !ok {
+			return nil, fmt.Errorf("%s: profile %s must be a table", path, name)
+		}
+		profile := &Profile{Name: name, Path: path, Values: make(map[string]any)}
+		for key, value := range values {
+			var field *string
+			switch key {
+			case "description":
+				field = &profile.Description
+			case "extends":
+				field = &profile.Extends
+			case "root":
+				field = &profile.Root
+			default:
+				profile.Values[key] = value
+				continue
+			}
+			s, ok := value.(string)
+			if !ok {
+				return nil, fmt.Errorf("%s: %s of profile %s must be a string", path, key, name)
+			}
+			*field = s
+		}
+		if profile.Root != "" && !filepath.IsAbs(profile.Root) {
+			profile.Root = filepath.Join(filepath.Dir(path), profile.Root)
+		}
+		profiles[name] = profile
+	}
+	return profiles, nil
+}
+
+// Profiles returns the profiles defined in the files by name. Project
+// profiles replace user profiles of the same name.
+func (files *Files) Profiles() map[string]*Profile {
+	profiles := make(map[string]*Profile)
+	for _, file := range []*File{files.User, files.Project} {
+		if file == nil {
+			continue
+		}
+		for name, profile := range file.Profiles {
+			profiles[name] = profile
+		}
+	}
+	return profiles
+}
+
+// Profile returns the named profile with the options and root it inherits
+// merged in.
+func (files *Files) Profile(name string) (*Profile, error) {
+	profiles := files.Profiles()
+
+	var chain []*Profile
+	seen := make(map[string]bool)
+	for current := name; current != ""; current = chain[len(chain)-1].Extends {
+		profile, ok := profiles[current]
+		switch {
+		case !ok && current == name:
+			return nil, fmt.Errorf("unknown profile %q", name)
+		case !ok:
+			return nil, fmt.Errorf("profile %s extends unknown profile %q", chain[len(chain)-1].Name, current)
+		case seen[current]:
+			return nil, fmt.Errorf("profile %s inherits from itself", current)
+		}
+		seen[current] = true
+		chain = append(chain, profile)
+	}
+
+	resolved := *chain[0]
+	resolved.Root = ""
+	resolved.Values = make(map[string]any)
+	for i := len(chain) - 1; i >= 0; i-- {
+		for key, value := range chain[i].Values {
+			resolved.Values[key] = value
+		}
+		if chain[i].Root != "" {
+			resolved.Root = chain[i].Root
+		}
+	}
+	return &resolved, nil
+}
@@ -0,0 +1,68 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestProfile(t *testing.T) {
+	root := t.TempDir()
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	content := `
+workers = 2
+
+[profiles.base]
+description = "Shared settings"
+root = "src"
+strip-comments = true
+exclude = ["*.log"]
+
+[profiles.backend]
+description = "Backend only"
+extends = "base"
+exclude = ["web/"]
+
+[profiles.loop-a]
+extends = "loop-b"
+
+[profiles.loop-b]
+extends = "loop-a"
+
+[profiles.orphan]
+extends = "missing"
+`
+	if err := os.WriteFile(filepath.Join(root, "dirmd.toml"), []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	files, err := Discover(root)
+	if err != nil {
+		t.Fatalf("Discover() error = %v", err)
+	}
+
+	profile, err := files.Profile("backend")
+	if err != nil {
+		t.Fatalf("Profile() error = %v", err)
+	}
+	expected := &Profile{
+		Name:        "backend",
+		Description: "Backend only",
+		Extends:     "base",
+		Root:        filepath.Join(root, "src"),
+		Path:        filepath.Join(root, "dirmd.toml"),
+		Values: map[string]any{
+			"strip-comments": true,
+			"exclude":        []any{"web/"},
+		},
+	}
+	if !reflect.DeepEqual(profile, expected) {
+		t.Errorf("Profile() = %+v; want %+v", profile, expected)
+	}
+
+	for _, name := range []string{"loop-a", "orphan", "unknown"} {
+		if _, err := files.Profile(name); err == nil {
+			t.Errorf("Profile(%q) succeeded; want an error", name)
+		}
+	}
+}
@@ -0,0 +1,95 @@
+// Package coverage reads the coverage profiles written by go test -coverprofile.
+package coverage
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// Block is a run of statements a profile records as a unit.
+type Block struct {
+	// File is the import path of the package followed by the file name.
+	File string
+	// StartLine and EndLine delimit the block, counting from 1.
+	StartLine, EndLine int
+	// Count is the number of times the block ran, or 0 or 1 in set mode.
+	Count int
+}
+
+// blockLine matches the lines of a profile after the mode line:
+//
+//	example.com/app/main.go:12.34,15.2 3 0
+var blockLine = regexp.MustCompile(`^(.+):(\d+)\.\d+,(\d+)\.\d+ \d+ (\d+)$`)
+
+// Parse reads a coverage profile. Blocks recorded more than once, as in
+// profiles merged from several runs, are combined.
+func Parse(r io.Reader) ([]Block, error) {
+	var blocks []Block
+	index := make(map[Block]int)
+	scanner := bufio.NewScanner(r)
+	for n := 1; scanner.Scan(); n++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || n == 1 && strings.HasPrefix(line, "mode:") {
+			continue
+		}
+		m := blockLine.FindStringSubmatch(line)
+		if m == nil {
+			return nil, fmt.Errorf("line %d: not a coverage block: %q", n, line)
+		}
+		block := Block{File: m[1]}
+		block.StartLine, _ = strconv.Atoi(m[2])
+		block.EndLine, _ = strconv.Atoi(m[3])
+		count, _ := strconv.Atoi(m[4])
+		if i, ok := index[block]; ok {
+			blocks[i].Count += count
+			continue
+		}
+		index[block] = len(blocks)
+		block.Count = count
+		blocks = append(blocks, block)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return blocks, nil
+}
+
+// Uncovered returns the lines of the blocks that never ran, in increasing
+// order, by file.
+func Uncovered(blocks []Block) map[string][]int {
+	lines := make(map[string][]int)
+	for _, block := range blocks {
+		if block.Count > 0 {
+			continue
+		}
+		for n := block.StartLine; n <= block.EndLine; n++ {
+			lines[block.File] = append(lines[block.File], n)
+		}
+	}
+	for file := range lines {
+		slices.Sort(lines[file])
+		lines[file] = slices.Compact(lines[file])
+	}
+	return lines
+}
+
+// ModulePath returns the module path declared by the go.mod file data, which
+// begins the import paths of the files of the module.
+func ModulePath(data []byte) (string, bool) {
+	for line := range strings.Lines(string(data)) {
+		line, _, _ = strings.Cut(line, "//")
+		fields := strings.Fields(line)
+		if len(fields) == 2 && fields[0] == "module" {
+			if path, err := strconv.Unquote(fields[1]); err == nil {
+				return path, true
+			}
+			return fields[1], true
+		}
+	}
+	return "", false
+}
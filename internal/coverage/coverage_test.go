@@ -0,0 +1,51 @@
+package coverage
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	profile := `mode: set
+example.com/app/main.go:5.13,7.2 1 1
+example.com/app/main.go:9.20,11.16 2 0
+example.com/app/main.go:11.16,13.3 1 0
+example.com/app/store/store.go:3.20,5.2 1 0
+example.com/app/store/store.go:3.20,5.2 1 1
+`
+	blocks, err := Parse(strings.NewReader(profile))
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+	if len(blocks) != 4 {
+		t.Fatalf("Parse() returned %d blocks; want 4", len(blocks))
+	}
+
+	expected := map[string][]int{"example.com/app/main.go": {9, 10, 11, 12, 13}}
+	if got := Uncovered(blocks); !reflect.DeepEqual(got, expected) {
+		t.Errorf("Uncovered() = %v; want %v", got, expected)
+	}
+
+	if _, err := Parse(strings.NewReader("mode: set\nnot a block\n")); err == nil {
+		t.Error("Parse() of an invalid profile succeeded; want an error")
+	}
+}
+
+func TestModulePath(t *testing.T) {
+	testCases := []struct {
+		gomod    string
+		expected string
+		ok       bool
+	}{
+		{gomod: "module example.com/app\n\ngo 1.24\n", expected: "example.com/app", ok: true},
+		{gomod: "// The app.\nmodule \"example.com/app\" // quoted\n", expected: "example.com/app", ok: true},
+		{gomod: "go 1.24\n", ok: false},
+	}
+
+	for _, tc := range testCases {
+		if got, ok := ModulePath([]byte(tc.gomod)); got != tc.expected || ok != tc.ok {
+			t.Errorf("ModulePath(%q) = %q, %v; want %q, %v", tc.gomod, got, ok, tc.expected, tc.ok)
+		}
+	}
+}
@@ -0,0 +1,142 @@
+// Package detect recognises generated, vendored and minified files.
+package detect
+
+import (
+	"bytes"
+	"fmt"
+	"path"
+	"regexp"
+	"strings"
+)
+
+// Category is a kind of file that is usually not worth bundling.
+type Category string
+
+const (
+	// Generated files are produced by tools, such as protobuf code or lockfiles.
+	Generated Category = "generated"
+	// Vendored files are third-party code copied into the repository.
+	Vendored Category = "vendored"
+	// Minified files are compressed web assets with very long lines.
+	Minified Category = "minified"
+)
+
+// Policy decides what happens to files of a category.
+type Policy string
+
+const (
+	// Include bundles the files like any other.
+	Include Policy = "include"
+	// Exclude leaves the files out entirely.
+	Exclude Policy = "exclude"
+	// List shows the files in the tree without their content.
+	List Policy = "list"
+)
+
+// ParsePolicy validates the name of a policy.
+func ParsePolicy(name string) (Policy, error) {
+	switch p := Policy(name); p {
+	case Include, Exclude, List:
+		return p, nil
+	}
+	return "", fmt.Errorf("unknown policy %q (want %s, %s or %s)", name, Include, Exclude, List)
+}
+
+// vendorDirs are directory names that hold third-party code.
+var vendorDirs = map[string]bool{
+	"bower_components": true,
+	"node_modules":     true,
+	"third-party":      true,
+	"third_party":      true,
+	"vendor":           true,
+}
+
+// lockfiles are files written by package managers.
+var lockfiles = map[string]bool{
+	"cargo.lock":         true,
+	"composer.lock":      true,
+	"flake.lock":         true,
+	"gemfile.lock":       true,
+	"go.sum":             true,
+	"mix.lock":           true,
+	"package-lock.json":  true,
+	"packages.lock.json": true,
+	"pipfile.lock":       true,
+	"pnpm-lock.yaml":     true,
+	"poetry.lock":        true,
+	"yarn.lock":          true,
+}
+
+// IsVendoredDir reports whether the directory at the slash-separated path holds vendored code.
+func IsVendoredDir(dir string) bool {
+	return vendorDirs[path.Base(dir)]
+}
+
+// IsVendoredPath reports whether any directory on the slash-separated path holds vendored code.
+func IsVendoredPath(p string) bool {
+	dirs := strings.Split(path.Dir(p), "/")
+	for _, dir := range dirs {
+		if vendorDirs[dir] {
+			return true
+		}
+	}
+	return false
+}
+
+// IsLockfile reports whether the file at the slash-separated path is a package manager lockfile.
+func IsLockfile(p string) bool {
+	return lockfiles[strings.ToLower(path.Base(p))]
+}
+
+// generatedHeader matches the markers code generators put near the top of
+// their output, such as Go's "// Code generated ... DO NOT EDIT." line.
+var generatedHeader = regexp.MustCompile(`(?m)^\W*(Code generated .* DO NOT EDIT\.?|@generated\b)`)
+
+// headerLines is the number of leading lines searched for a generated header.
+const headerLines = 30
+
+// HasGeneratedHeader reports whether content starts with a code generator marker.
+func HasGeneratedHeader(content []byte) bool {
+	head := content
+	for i, n := 0, 0; i < len(content); i++ {
+		if content[i] == '\n' {
+			n++
+			if n == headerLines {
+				head = content[:i]
+				break
+			}
+		}
+	}
+	return generatedHeader.Match(head)
+}
+
+// minifiableLanguages are the languages whose files are commonly minified.
+var minifiableLanguages = map[string]bool{
+	"css":        true,
+	"html":       true,
+	"javascript": true,
+	"json":       true,
+	"svg":        true,
+	"xml":        true,
+}
+
+const (
+	// minifiedLineLength is the average line length above which a file counts as minified.
+	minifiedLineLength = 300
+	// minifiedMinSize keeps small one-line files from counting as minified.
+	minifiedMinSize = 1024
+)
+
+// IsMinified reports whether the file at the slash-separated path, written in
+// lang, is minified, judged by its name and by its average line length.
+func IsMinified(p, lang string, content []byte) bool {
+	name := strings.ToLower(path.Base(p))
+	if strings.Contains(name, ".min.") {
+		return true
+	}
+	if !minifiableLanguages[lang] || len(content) < minifiedMinSize {
+		return false
+	}
+	lines := bytes.Count(content, []byte("\n")) + 1
+	return len(content)/lines > minifiedLineLength
+}
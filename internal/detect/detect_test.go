@@ -0,0 +1,92 @@
+package detect
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestIsVendoredPath(t *testing.T) {
+	testCases := map[string]bool{
+		"vendor/github.com/x/y.go":      true,
+		"web/node_modules/react/a.js":   true,
+		"third_party/zlib/zlib.h":       true,
+		"internal/vendorlib/a.go":       false,
+		"vendor.go":                     false,
+		"docs/third_party_notices.md":   false,
+		"src/bower_components/x/y.css":  true,
+		"cmd/vendor/tool/main.go":       true,
+		"internal/processor/vendor.txt": false,
+	}
+	for path, expected := range testCases {
+		if got := IsVendoredPath(path); got != expected {
+			t.Errorf("IsVendoredPath(%q) = %v; want %v", path, got, expected)
+		}
+	}
+}
+
+func TestHasGeneratedHeader(t *testing.T) {
+	testCases := []struct {
+		name     string
+		content  string
+		expected bool
+	}{
+		{
+			name:     "Go generated header",
+			content:  "// Code generated by protoc-gen-go. DO NOT EDIT.\n\npackage pb\n",
+			expected: true,
+		},
+		{
+			name:     "Header after build tags",
+			content:  "//go:build linux\n\n// Code generated by stringer; DO NOT EDIT.\npackage x\n",
+			expected: true,
+		},
+		{
+			name:     "Hash comment marker",
+			content:  "# @generated by tool\nkey: value\n",
+			expected: true,
+		},
+		{
+			name:     "Mention in prose",
+			content:  "package x\n\n// The Code generated here is fine to edit.\n",
+			expected: false,
+		},
+		{
+			name:     "Header too far down",
+			content:  strings.Repeat("\n", headerLines+1) + "// Code generated by x. DO NOT EDIT.\n",
+			expected: false,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := HasGeneratedHeader([]byte(tc.content)); got != tc.expected {
+				t.Errorf("HasGeneratedHeader() = %v; want %v", got, tc.expected)
+			}
+		})
+	}
+}
+
+func TestIsMinified(t *testing.T) {
+	long := strings.Repeat("var a=1;", 200)
+	testCases := []struct {
+		name     string
+		path     string
+		lang     string
+		content  string
+		expected bool
+	}{
+		{"Minified name", "dist/app.min.js", "javascript", "a", true},
+		{"Long lines", "dist/app.js", "javascript", long, true},
+		{"Normal source", "src/app.js", "javascript", strings.Repeat("var a = 1;\n", 200), false},
+		{"Long lines in prose", "docs/notes.txt", "txt", long, false},
+		{"Small file", "src/tiny.js", "javascript", "var a=1;", false},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := IsMinified(tc.path, tc.lang, []byte(tc.content)); got != tc.expected {
+				t.Errorf("IsMinified(%q) = %v; want %v", tc.path, got, tc.expected)
+			}
+		})
+	}
+}
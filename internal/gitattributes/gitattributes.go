@@ -0,0 +1,98 @@
+// Package gitattributes reads attributes assigned to paths by a .gitattributes file.
+package gitattributes
+
+import (
+	"bufio"
+	"os"
+	"path/filepath"
+	"strings"
+
+	ignore "github.com/sabhiram/go-gitignore"
+)
+
+// Attributes holds the rules of a .gitattributes file.
+type Attributes struct {
+	rules []rule
+}
+
+type rule struct {
+	pattern *ignore.GitIgnore
+	// attrs maps attribute names to their value. Set attributes have the
+	// value "true", unset ones "false" and unspecified ones "".
+	attrs map[string]string
+}
+
+// Load reads the .gitattributes file at the root of rootDir.
+// A missing file yields empty Attributes.
+func Load(rootDir string) (*Attributes, error) {
+	file, err := os.Open(filepath.Join(rootDir, ".gitattributes"))
+	if os.IsNotExist(err) {
+		return &Attributes{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	var lines []string
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		lines = append(lines, scanner.Text())
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return Parse(lines), nil
+}
+
+// Parse builds Attributes from the lines of a .gitattributes file.
+func Parse(lines []string) *Attributes {
+	a := &Attributes{}
+	for _, line := range lines {
+		fields := strings.Fields(line)
+		if len(fields) < 2 || strings.HasPrefix(fields[0], "#") {
+			continue
+		}
+		r := rule{
+			pattern: ignore.CompileIgnoreLines(fields[0]),
+			attrs:   make(map[string]string),
+		}
+		for _, field := range fields[1:] {
+			switch {
+			case strings.HasPrefix(field, "-"):
+				r.attrs[field[1:]] = "false"
+			case strings.HasPrefix(field, "!"):
+				r.attrs[field[1:]] = ""
+			default:
+				name, value, ok := strings.Cut(field, "=")
+				if !ok {
+					value = "true"
+				}
+				r.attrs[name] = value
+			}
+		}
+		a.rules = append(a.rules, r)
+	}
+	return a
+}
+
+// Value returns the value of attribute name for the slash-separated path
+// relative to the root. The last matching rule that mentions the attribute
+// wins. ok is false if the attribute is not specified for path.
+func (a *Attributes) Value(path, name string) (value string, ok bool) {
+	for i := len(a.rules) - 1; i >= 0; i-- {
+		r := a.rules[i]
+		v, mentioned := r.attrs[name]
+		if !mentioned || !r.pattern.MatchesPath(path) {
+			continue
+		}
+		return v, v != ""
+	}
+	return "", false
+}
+
+// IsSet reports whether attribute name is set to true for path.
+func (a *Attributes) IsSet(path, name string) bool {
+	v, ok := a.Value(path, name)
+	return ok && v == "true"
+}
@@ -0,0 +1,61 @@
+package gitattributes
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestAttributes(t *testing.T) {
+	rootDir := t.TempDir()
+	content := `
+# Comments should be ignored
+*.pb.go linguist-generated
+api/** linguist-generated=true
+api/handwritten.go -linguist-generated
+third_party/** linguist-vendored
+*.tmpl linguist-language=Go
+legacy/*.tmpl !linguist-language
+`
+	err := os.WriteFile(filepath.Join(rootDir, ".gitattributes"), []byte(content), 0644)
+	if err != nil {
+		t.Fatalf("Failed to write .gitattributes: %v", err)
+	}
+
+	attrs, err := Load(rootDir)
+	if err != nil {
+		t.Fatalf("Load() failed: %v", err)
+	}
+
+	testCases := []struct {
+		path          string
+		attr          string
+		expectedValue string
+		expectedOK    bool
+	}{
+		{"proto/service.pb.go", "linguist-generated", "true", true},
+		{"api/client.go", "linguist-generated", "true", true},
+		{"api/handwritten.go", "linguist-generated", "false", true},
+		{"main.go", "linguist-generated", "", false},
+		{"third_party/lib/lib.c", "linguist-vendored", "true", true},
+		{"views/page.tmpl", "linguist-language", "Go", true},
+		{"legacy/page.tmpl", "linguist-language", "", false},
+	}
+
+	for _, tc := range testCases {
+		value, ok := attrs.Value(tc.path, tc.attr)
+		if value != tc.expectedValue || ok != tc.expectedOK {
+			t.Errorf("Value(%q, %q) = %q, %v; want %q, %v", tc.path, tc.attr, value, ok, tc.expectedValue, tc.expectedOK)
+		}
+	}
+
+	t.Run("missing file", func(t *testing.T) {
+		attrs, err := Load(t.TempDir())
+		if err != nil {
+			t.Fatalf("Load() without .gitattributes failed: %v", err)
+		}
+		if attrs.IsSet("any/file.go", "linguist-generated") {
+			t.Error("Expected no attributes without a .gitattributes file")
+		}
+	})
+}
@@ -0,0 +1,207 @@
+package processor
+
+import (
+	"bytes"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// modelineLines is how many lines at each end of a file are searched for a Vim modeline.
+const modelineLines = 5
+
+var (
+	vimModeline   = regexp.MustCompile(`(?:^|\s)(?:vi|vim|ex):.*?\b(?:ft|filetype|syntax)=([\w+#.-]+)`)
+	emacsModeline = regexp.MustCompile(`-\*-\s*(.+?)\s*-\*-`)
+)
+
+// languageAliases maps the names editors and GitHub Linguist use for
+// languages to the Markdown identifiers of langExtMap.
+var languageAliases = map[string]string{
+	"c#":          "csharp",
+	"c++":         "cpp",
+	"emacs-lisp":  "elisp",
+	"emacs lisp":  "elisp",
+	"f#":          "fsharp",
+	"golang":      "go",
+	"js":          "javascript",
+	"make":        "makefile",
+	"objc":        "objectivec",
+	"objective-c": "objectivec",
+	"py":          "python",
+	"python3":     "python",
+	"rb":          "ruby",
+	"sh":          "shell",
+	"ts":          "typescript",
+	"vim script":  "vim",
+	"yml":         "yaml",
+}
+
+// interpreterLanguages maps the interpreters of shebang lines, without
+// version numbers, to Markdown language identifiers.
+var interpreterLanguages = map[string]string{
+	"ash":        "shell",
+	"awk":        "awk",
+	"bash":       "bash",
+	"bun":        "javascript",
+	"dash":       "shell",
+	"deno":       "typescript",
+	"elixir":     "elixir",
+	"escript":    "erlang",
+	"fish":       "fish",
+	"gawk":       "awk",
+	"groovy":     "groovy",
+	"guile":      "scheme",
+	"julia":      "julia",
+	"ksh":        "shell",
+	"lua":        "lua",
+	"make":       "makefile",
+	"node":       "javascript",
+	"nodejs":     "javascript",
+	"perl":       "perl",
+	"php":        "php",
+	"pwsh":       "powershell",
+	"python":     "python",
+	"racket":     "racket",
+	"rscript":    "r",
+	"ruby":       "ruby",
+	"runhaskell": "haskell",
+	"sbcl":       "lisp",
+	"scala":      "scala",
+	"sh":         "shell",
+	"swift":      "swift",
+	"tclsh":      "tcl",
+	"ts-node":    "typescript",
+	"zsh":        "zsh",
+}
+
+// detectLanguage determines the language of a file from, in order, the
+// override in opts, the mappings in opts, a modeline, the built-in
+// mappings and the shebang line, falling back to the extension.
+func detectLanguage(path string, content []byte, opts Options) string {
+	if opts.Language != "" {
+		return normalizeLanguage(opts.Language)
+	}
+	if lang, ok := lookupLanguage(path, opts.Languages); ok {
+		return lang
+	}
+	if lang := modelineLanguage(content); lang != "" {
+		return lang
+	}
+	if lang, ok := lookupLanguage(path, langExtMap); ok {
+		return lang
+	}
+	if lang := shebangLanguage(content); lang != "" {
+		return lang
+	}
+	return getLanguage(path)
+}
+
+// PathLanguage determines the language of the file at path without reading
+// it, from the override and the mappings in opts, the built-in mappings and
+// the extension.
+func PathLanguage(path string, opts Options) string {
+	return detectLanguage(path, nil, opts)
+}
+
+// lookupLanguage finds the language of path in mappings from lowercase
+// file names and extensions, checking the file name first.
+func lookupLanguage(path string, mappings map[string]string) (string, bool) {
+	filename := strings.ToLower(filepath.Base(path))
+	if lang, ok := mappings[filename]; ok {
+		return lang, true
+	}
+	lang, ok := mappings[filepath.Ext(filename)]
+	return lang, ok
+}
+
+// normalizeLanguage turns a language name into a Markdown language identifier.
+func normalizeLanguage(name string) string {
+	name = strings.ToLower(strings.TrimSpace(name))
+	if alias, ok := languageAliases[name]; ok {
+		return alias
+	}
+	return strings.ReplaceAll(name, " ", "-")
+}
+
+// modelineLanguage returns the language set by an Emacs modeline in the
+// first two lines or a Vim modeline in the first or last lines of content.
+func modelineLanguage(content []byte) string {
+	lines := bytes.SplitN(content, []byte("\n"), modelineLines+1)
+	for i, line := range lines[:min(len(lines), 2)] {
+		if i == 1 && !bytes.HasPrefix(lines[0], []byte("#!")) {
+			break
+		}
+		if m := emacsModeline.FindSubmatch(line); m != nil {
+			if lang := emacsMode(string(m[1])); lang != "" {
+				return lang
+			}
+		}
+	}
+
+	tail := content
+	if i := lastLinesStart(content, modelineLines); i > 0 {
+		tail = content[i:]
+	}
+	for _, region := range [][]byte{bytes.Join(lines[:min(len(lines), modelineLines)], []byte("\n")), tail} {
+		if m := vimModeline.FindSubmatch(region); m != nil {
+			return normalizeLanguage(string(m[1]))
+		}
+	}
+	return ""
+}
+
+// emacsMode returns the major mode of the variables of an Emacs modeline,
+// which are either just the mode or "mode: name;" pairs.
+func emacsMode(vars string) string {
+	if !strings.Contains(vars, ":") {
+		return normalizeLanguage(vars)
+	}
+	for v := range strings.SplitSeq(vars, ";") {
+		key, value, ok := strings.Cut(v, ":")
+		if ok && strings.EqualFold(strings.TrimSpace(key), "mode") {
+			return normalizeLanguage(value)
+		}
+	}
+	return ""
+}
+
+// lastLinesStart returns the offset of the last n lines of content.
+func lastLinesStart(content []byte, n int) int {
+	end := len(bytes.TrimRight(content, "\n"))
+	for i := end - 1; i >= 0; i-- {
+		if content[i] == '\n' {
+			n--
+			if n == 0 {
+				return i + 1
+			}
+		}
+	}
+	return 0
+}
+
+// shebangLanguage returns the language of the interpreter named by the
+// shebang line of content, such as python for "#!/usr/bin/env python3".
+func shebangLanguage(content []byte) string {
+	if !bytes.HasPrefix(content, []byte("#!")) {
+		return ""
+	}
+	line, _, _ := bytes.Cut(content[2:], []byte("\n"))
+	fields := strings.Fields(string(line))
+	if len(fields) == 0 {
+		return ""
+	}
+	interpreter := filepath.Base(fields[0])
+	if interpreter == "env" {
+		interpreter = ""
+		for _, field := range fields[1:] {
+			// Skip the options and variables of env.
+			if !strings.HasPrefix(field, "-") && !strings.Contains(field, "=") {
+				interpreter = filepath.Base(field)
+				break
+			}
+		}
+	}
+	interpreter = strings.ToLower(strings.TrimRight(interpreter, "0123456789."))
+	return interpreterLanguages[interpreter]
+}
@@ -0,0 +1,95 @@
+package processor
+
+import "testing"
+
+func TestDetectLanguage(t *testing.T) {
+	testCases := []struct {
+		name     string
+		path     string
+		content  string
+		opts     Options
+		expected string
+	}{
+		{
+			name:     "Extension",
+			path:     "main.go",
+			content:  "package main\n",
+			expected: "go",
+		},
+		{
+			name:     "Shebang with env",
+			path:     "bin/deploy",
+			content:  "#!/usr/bin/env python3\nprint('hi')\n",
+			expected: "python",
+		},
+		{
+			name:     "Shebang with env options",
+			path:     "run",
+			content:  "#!/usr/bin/env -S node --harmony\n",
+			expected: "javascript",
+		},
+		{
+			name:     "Shebang with a path",
+			path:     "setup",
+			content:  "#!/bin/bash -e\n",
+			expected: "bash",
+		},
+		{
+			name:     "Shebang does not override the extension",
+			path:     "script.rb",
+			content:  "#!/bin/sh\nexec ruby -x $0\n",
+			expected: "ruby",
+		},
+		{
+			name:     "Vim modeline at the end",
+			path:     "config.h",
+			content:  "#define X 1\n\n// vim: set ts=4 ft=cpp:\n",
+			expected: "cpp",
+		},
+		{
+			name:     "Emacs modeline",
+			path:     "util.h",
+			content:  "/* -*- C++ -*- */\n",
+			expected: "cpp",
+		},
+		{
+			name:     "Emacs modeline with variables after a shebang",
+			path:     "tool",
+			content:  "#!/bin/sh\n# -*- mode: python; coding: utf-8 -*-\n",
+			expected: "python",
+		},
+		{
+			name:     "User mapping by extension",
+			path:     "page.tmpl",
+			content:  "{{ .Title }}\n",
+			opts:     Options{Languages: map[string]string{".tmpl": "gotemplate"}},
+			expected: "gotemplate",
+		},
+		{
+			name:     "User mapping by file name",
+			path:     "ci/Jenkinsfile",
+			opts:     Options{Languages: map[string]string{"jenkinsfile": "groovy"}},
+			expected: "groovy",
+		},
+		{
+			name:     "Linguist override",
+			path:     "queries.txt",
+			opts:     Options{Language: "Objective-C", Languages: map[string]string{".txt": "text"}},
+			expected: "objectivec",
+		},
+		{
+			name:     "Unknown",
+			path:     "LICENSE",
+			content:  "MIT License\n",
+			expected: "",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := detectLanguage(tc.path, []byte(tc.content), tc.opts); got != tc.expected {
+				t.Errorf("detectLanguage() = %q; want %q", got, tc.expected)
+			}
+		})
+	}
+}
@@ -2,10 +2,15 @@ package processor
 
 import (
 	"bytes"
+	"errors"
 	"fmt"
-	"os"
 	"path/filepath"
 	"strings"
+
+	"github.com/axseem/dirmd/internal/charset"
+	"github.com/axseem/dirmd/internal/detect"
+	"github.com/axseem/dirmd/internal/transform"
+	"github.com/axseem/dirmd/internal/unicodescan"
 )
 
 // langExtMap maps file extensions and specific filenames to Markdown language identifiers.
@@ -107,44 +112,194 @@ type Result struct {
 	Language  string
 	IsBinary  bool
 	ReadError error
+	// SkipReason is set if the content was left out on purpose before it was
+	// processed, such as for files too large to read.
+	SkipReason string
+	// Encoding is the encoding the file was read in. Content is always UTF-8.
+	Encoding charset.Encoding
+	// Category is the kind of file recognised from its content, if any.
+	Category detect.Category
+	// Savings records how much each applied transform shrank the content.
+	Savings []Saving
+	// Findings lists the issues scanners found in the content.
+	Findings []Finding
+	// Truncated reports whether lines were left out to respect the size limits.
+	Truncated bool
+	// Excerpted reports whether only the regions matching Options.Grep were kept.
+	Excerpted bool
+	// TotalLines is the number of lines of the content before truncation.
+	TotalLines int
+	// OmittedLines is the number of lines left out by truncation or excerpting.
+	OmittedLines int
+	// LineNumbers reports whether the lines of Content are numbered.
+	LineNumbers bool
+}
+
+// Finding is an issue a scanner found in a file's content.
+type Finding struct {
+	// Scanner names the scan that found the issue.
+	Scanner string `json:"scanner"`
+	// Rule names what was found, such as the type of a secret.
+	Rule string `json:"rule"`
+	// Line is the line of the content the issue is on, counting from 1.
+	Line int `json:"line"`
+	// Text is the offending text, if it is safe to show.
+	Text string `json:"text,omitempty"`
 }
 
-// ProcessFile reads a file and returns its raw content and metadata.
-func ProcessFile(path string) Result {
-	content, err := os.ReadFile(path)
+// UnicodeScanner is the name findings of the Unicode scan are recorded under.
+const UnicodeScanner = "unicode"
+
+// ProcessFile reads a file and returns its content, transformed according to opts, and metadata.
+func ProcessFile(path string, opts Options) Result {
+	content, complete, err := readFile(path, opts.MaxReadBytes, opts.ReadTimeout)
+	var skip *skipError
+	if errors.As(err, &skip) {
+		return Result{Path: path, SkipReason: skip.reason}
+	}
 	if err != nil {
 		return Result{Path: path, ReadError: fmt.Errorf("reading file: %w", err)}
 	}
 
-	if isBinary(content) {
-		return Result{Path: path, IsBinary: true}
+	enc := charset.Detect(content)
+	if enc == charset.Binary {
+		return Result{Path: path, IsBinary: true, Encoding: enc}
+	}
+	if !complete {
+		return Result{Path: path, Encoding: enc, SkipReason: SkipTooLarge}
+	}
+	content, err = charset.Decode(content, enc)
+	if err != nil {
+		return Result{Path: path, Encoding: enc, ReadError: fmt.Errorf("decoding %s: %w", enc, err)}
+	}
+	if bytes.HasPrefix(content, []byte(BundleSignature)) {
+		return Result{Path: path, Encoding: enc, SkipReason: SkipBundle}
+	}
+	if opts.MaxLines > 0 && transform.CountLines(content) > opts.MaxLines {
+		return Result{Path: path, Encoding: enc, SkipReason: fmt.Sprintf("longer than %d lines", opts.MaxLines)}
+	}
+
+	lang := detectLanguage(path, content, opts)
+	category := detectCategory(path, lang, content)
+	findings, content := checkUnicode(content, opts.UnicodeCheck)
+	if opts.Scan != nil {
+		// Scanning the whole file keeps truncation from cutting a match in
+		// two, and numbers the findings by the lines of the file.
+		found, scanned := opts.Scan(content, lang, category)
+		findings, content = append(findings, found...), scanned
+	}
+
+	if len(opts.Lines) > 0 {
+		// The lines are numbered as in the file, so none may be removed.
+		opts.StripComments, opts.CollapseBlankLines = false, false
+	}
+	content, savings, lineMap := applyTransforms(path, content, lang, opts)
+
+	var matches []int
+	if opts.Grep != nil {
+		if matches = transform.MatchingLines(content, opts.Grep); len(matches) == 0 {
+			return Result{Path: path, Encoding: enc, Language: lang, SkipReason: SkipNoMatch}
+		}
 	}
 
-	lang := getLanguage(path)
+	var focus []int
+	if opts.Grep != nil && opts.GrepContext >= 0 {
+		focus = append(focus, around(matches, opts.GrepContext)...)
+	}
+	if len(opts.Lines) > 0 && opts.LinesContext >= 0 {
+		focus = append(focus, around(opts.Lines, opts.LinesContext)...)
+	}
+
+	totalLines := transform.CountLines(content)
+	var regions []transform.Region
+	var truncated bool
+	excerpted := len(focus) > 0
+	if excerpted {
+		regions = transform.Excerpt(content, focus, 0)
+	} else {
+		regions, truncated = transform.Truncate(content, opts.Limits)
+	}
+	omitted := totalLines
+	for _, r := range regions {
+		omitted -= transform.CountLines(r.Content)
+	}
+	var marked map[int]bool
+	if len(opts.Lines) > 0 {
+		marked = make(map[int]bool, len(opts.Lines))
+		for _, n := range opts.Lines {
+			marked[n] = true
+		}
+	}
+	numbered := opts.LineNumbers || excerpted || marked != nil
+	if truncated || numbered {
+		content = transform.JoinRegions(regions, totalLines, transform.JoinOptions{
+			LineNumbers:      numbered,
+			LineNumberFormat: opts.LineNumberFormat,
+			Relative:         opts.LineNumbersRelative,
+			Marked:           marked,
+			LineMap:          lineMap,
+		})
+	}
 
 	return Result{
-		Path:     path,
-		Content:  content,
-		Language: lang,
+		Path:         path,
+		Content:      content,
+		Language:     lang,
+		Encoding:     enc,
+		Category:     category,
+		Savings:      savings,
+		Findings:     findings,
+		Truncated:    truncated,
+		Excerpted:    excerpted,
+		TotalLines:   totalLines,
+		OmittedLines: omitted,
+		LineNumbers:  numbered,
+	}
+}
+
+// around returns lines along with the context lines before and after each of them.
+func around(lines []int, context int) []int {
+	out := make([]int, 0, len(lines)*(2*context+1))
+	for _, n := range lines {
+		for i := n - context; i <= n+context; i++ {
+			out = append(out, i)
+		}
 	}
+	return out
 }
 
 // getLanguage determines the language for syntax highlighting.
 // It first checks the full filename, then the file extension.
 func getLanguage(path string) string {
-	filename := strings.ToLower(filepath.Base(path))
-	if lang, ok := langExtMap[filename]; ok {
+	if lang, ok := lookupLanguage(path, langExtMap); ok {
 		return lang
 	}
+	return strings.TrimPrefix(filepath.Ext(strings.ToLower(filepath.Base(path))), ".")
+}
 
-	ext := filepath.Ext(filename)
-	if lang, ok := langExtMap[ext]; ok {
-		return lang
+// detectCategory recognises generated and minified files by their content.
+func detectCategory(path, lang string, content []byte) detect.Category {
+	switch {
+	case detect.HasGeneratedHeader(content):
+		return detect.Generated
+	case detect.IsMinified(filepath.ToSlash(path), lang, content):
+		return detect.Minified
 	}
-
-	return strings.TrimPrefix(ext, ".")
+	return ""
 }
 
-func isBinary(data []byte) bool {
-	return bytes.Contains(data, []byte{0})
+// checkUnicode scans content for suspicious Unicode characters and escapes
+// them if the mode asks for it. Lines refer to the untransformed content.
+func checkUnicode(content []byte, mode unicodescan.Mode) ([]Finding, []byte) {
+	if mode == "" || mode == unicodescan.ModeOff {
+		return nil, content
+	}
+	var findings []Finding
+	for _, f := range unicodescan.Scan(content) {
+		findings = append(findings, Finding{Scanner: UnicodeScanner, Rule: f.Rule, Line: f.Line, Text: f.Text})
+	}
+	if mode == unicodescan.ModeEscape && len(findings) > 0 {
+		content = unicodescan.Escape(content)
+	}
+	return findings, content
 }
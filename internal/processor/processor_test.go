@@ -1,6 +1,9 @@
 package processor
 
 import (
+	"os"
+	"path/filepath"
+	"regexp"
 	"testing"
 )
 
@@ -67,55 +70,110 @@ func TestGetLanguage(t *testing.T) {
 	}
 }
 
-func TestIsBinary(t *testing.T) {
-	testCases := []struct {
-		name     string
-		data     []byte
-		expected bool
-	}{
-		{
-			name:     "Simple text",
-			data:     []byte("hello world"),
-			expected: false,
-		},
-		{
-			name:     "UTF-8 text",
-			data:     []byte("こんにちは"),
-			expected: false,
-		},
-		{
-			name:     "Text with newline and tab",
-			data:     []byte("line 1\n\tline 2"),
-			expected: false,
-		},
-		{
-			name:     "Data with null byte",
-			data:     []byte("this is a \x00 binary"),
-			expected: true,
-		},
-		{
-			name:     "Image file header (PNG)",
-			data:     []byte{0x89, 0x50, 0x4E, 0x47, 0x0D, 0x0A, 0x1A, 0x0A},
-			expected: false,
-		},
-		{
-			name:     "ELF executable header",
-			data:     []byte{0x7f, 'E', 'L', 'F', 0x02, 0x01, 0x01, 0x00},
-			expected: true,
-		},
-		{
-			name:     "Empty data",
-			data:     []byte{},
-			expected: false,
-		},
+func TestProcessFileSkipsBundles(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "bundle.md")
+	if err := os.WriteFile(path, []byte(BundleSignature+"\n\n# Structure of `x`\n"), 0o644); err != nil {
+		t.Fatal(err)
 	}
+	if got := ProcessFile(path, Options{}); got.SkipReason != SkipBundle {
+		t.Errorf("ProcessFile().SkipReason = %q; want %q", got.SkipReason, SkipBundle)
+	}
+}
 
-	for _, tc := range testCases {
-		t.Run(tc.name, func(t *testing.T) {
-			isBin := isBinary(tc.data)
-			if isBin != tc.expected {
-				t.Errorf("isBinary() = %v; want %v", isBin, tc.expected)
-			}
-		})
+func TestProcessFileSkipsLongFiles(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "long.txt")
+	if err := os.WriteFile(path, []byte("a\nb\nc\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if got := ProcessFile(path, Options{MaxLines: 3}); got.SkipReason != "" {
+		t.Errorf("ProcessFile() with 3 lines SkipReason = %q; want none", got.SkipReason)
+	}
+	if got := ProcessFile(path, Options{MaxLines: 2}); got.SkipReason != "longer than 2 lines" {
+		t.Errorf("ProcessFile() with 3 lines SkipReason = %q; want %q", got.SkipReason, "longer than 2 lines")
+	}
+}
+
+func TestProcessFileGrep(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "notes.txt")
+	if err := os.WriteFile(path, []byte("alpha\nbeta\ngamma\ndelta\nepsilon\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	if got := ProcessFile(path, Options{Grep: regexp.MustCompile("omega")}); got.SkipReason != SkipNoMatch {
+		t.Errorf("ProcessFile() without a match SkipReason = %q; want %q", got.SkipReason, SkipNoMatch)
+	}
+
+	whole := ProcessFile(path, Options{Grep: regexp.MustCompile("gamma"), GrepContext: -1})
+	if whole.SkipReason != "" || whole.Excerpted || string(whole.Content) != "alpha\nbeta\ngamma\ndelta\nepsilon\n" {
+		t.Errorf("ProcessFile() keeping whole files = %+v; want the whole content", whole)
+	}
+
+	excerpt := ProcessFile(path, Options{Grep: regexp.MustCompile("gamma"), GrepContext: 1})
+	expected := "... [1 line omitted] ...\n2 | beta\n3 | gamma\n4 | delta\n... [1 line omitted] ...\n"
+	if string(excerpt.Content) != expected || !excerpt.Excerpted || excerpt.OmittedLines != 2 {
+		t.Errorf("ProcessFile() excerpt = %q (omitted %d); want %q (omitted 2)", excerpt.Content, excerpt.OmittedLines, expected)
+	}
+}
+
+func TestProcessFileMarksLines(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "main.go")
+	if err := os.WriteFile(path, []byte("package main\n\n// main runs.\nfunc main() {\n\tpanic(1)\n}\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	whole := ProcessFile(path, Options{Lines: []int{5}, LinesContext: -1, StripComments: true})
+	expected := "  1 | package main\n  2 |\n  3 | // main runs.\n  4 | func main() {\n> 5 | \tpanic(1)\n  6 | }\n"
+	if string(whole.Content) != expected {
+		t.Errorf("ProcessFile() marking lines = %q; want %q", whole.Content, expected)
+	}
+
+	excerpt := ProcessFile(path, Options{Lines: []int{5}, LinesContext: 0})
+	expected = "... [4 lines omitted] ...\n> 5 | \tpanic(1)\n... [1 line omitted] ...\n"
+	if string(excerpt.Content) != expected || !excerpt.Excerpted {
+		t.Errorf("ProcessFile() excerpting lines = %q; want %q", excerpt.Content, expected)
+	}
+}
+
+func TestProcessFileNumbersSourceLines(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "main.go")
+	if err := os.WriteFile(path, []byte("package main\n\n\n// main runs.\nfunc main() {\n\tpanic(1)\n}\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	got := ProcessFile(path, Options{LineNumbers: true, StripComments: true, CollapseBlankLines: true})
+	expected := "1 | package main\n2 |\n5 | func main() {\n6 | \tpanic(1)\n7 | }\n"
+	if string(got.Content) != expected {
+		t.Errorf("ProcessFile() = %q; want %q", got.Content, expected)
+	}
+}
+
+func TestProcessFileGrepNumbersSourceLines(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "main.go")
+	if err := os.WriteFile(path, []byte("package main\n\n// main runs.\n// It panics.\nfunc main() {\n\tpanic(1)\n}\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	got := ProcessFile(path, Options{Grep: regexp.MustCompile("panic"), GrepContext: 1, StripComments: true})
+	expected := "... [2 lines omitted] ...\n5 | func main() {\n6 | \tpanic(1)\n7 | }\n"
+	if string(got.Content) != expected {
+		t.Errorf("ProcessFile() = %q; want %q", got.Content, expected)
+	}
+}
+
+func TestProcessFileKeepsMarkdownHardBreaks(t *testing.T) {
+	dir := t.TempDir()
+	content := []byte("first  \nsecond \t\n")
+	for name, expected := range map[string]string{
+		"notes.md": "first  \nsecond \t\n",
+		"notes.go": "first\nsecond\n",
+	} {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, content, 0o644); err != nil {
+			t.Fatal(err)
+		}
+		got := ProcessFile(path, Options{TrimTrailingWhitespace: true})
+		if string(got.Content) != expected {
+			t.Errorf("ProcessFile(%s) = %q; want %q", name, got.Content, expected)
+		}
 	}
 }
@@ -0,0 +1,153 @@
+package processor
+
+import (
+	"errors"
+	"io"
+	"io/fs"
+	"os"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/axseem/dirmd/internal/charset"
+)
+
+// Reasons a file's content is left out before it is processed, as reported in Result.SkipReason.
+const (
+	SkipTooLarge = "too large"
+	SkipTimeout  = "read timed out"
+	SkipBundle   = "dirmd bundle"
+	SkipNoMatch  = "no match"
+)
+
+// BundleSignature starts every bundle, so that bundles are never bundled again.
+const BundleSignature = "<!-- Bundled by dirmd. -->"
+
+// lineNumbersNote starts the line of a bundle header that records the format
+// of the line numbers in the bundle.
+const lineNumbersNote = "<!-- Line numbers: "
+
+// BundleHeader returns the header a bundle starts with: the signature,
+// followed, if lineNumberFormat is not empty, by a note recording it, so that
+// the line numbers can be stripped again with transform.StripLineNumbers.
+func BundleHeader(lineNumberFormat string) string {
+	if lineNumberFormat == "" {
+		return BundleSignature
+	}
+	// The format is quoted and cannot end the comment early.
+	quoted := strings.ReplaceAll(strconv.Quote(lineNumberFormat), ">", `\x3e`)
+	return BundleSignature + "\n" + lineNumbersNote + quoted + " -->"
+}
+
+// ParseBundleHeader splits a bundle into the header written by BundleHeader
+// and the rest, and returns the line number format the header records, if
+// any. ok is false if bundle does not start with the signature.
+func ParseBundleHeader(bundle string) (header, body, lineNumberFormat string, ok bool) {
+	body, ok = strings.CutPrefix(bundle, BundleSignature)
+	if !ok {
+		return "", bundle, "", false
+	}
+	if rest, found := strings.CutPrefix(body, "\n"+lineNumbersNote); found {
+		note, _, _ := strings.Cut(rest, "\n")
+		if format, err := strconv.Unquote(strings.TrimSuffix(note, " -->")); err == nil {
+			lineNumberFormat, body = format, rest[len(note):]
+		}
+	}
+	return bundle[:len(bundle)-len(body)], body, lineNumberFormat, true
+}
+
+// skipError is returned by readFile for files that are not read on purpose.
+type skipError struct {
+	reason string
+}
+
+func (e *skipError) Error() string {
+	return e.reason
+}
+
+// SpecialFileKind describes files of the given mode that are not regular
+// files, directories or symlinks, such as named pipes. It returns an empty
+// string for the others.
+func SpecialFileKind(mode fs.FileMode) string {
+	switch {
+	case mode.IsRegular(), mode.IsDir(), mode&fs.ModeSymlink != 0:
+		return ""
+	case mode&fs.ModeNamedPipe != 0:
+		return "named pipe"
+	case mode&fs.ModeSocket != 0:
+		return "socket"
+	case mode&fs.ModeDevice != 0:
+		return "device"
+	}
+	return "irregular file"
+}
+
+// readFile reads the file at path, giving up after timeout if it is
+// positive. Files larger than maxBytes, if it is positive, are only read up to
+// a prefix long enough to tell whether they are binary, and complete is false.
+func readFile(path string, maxBytes int64, timeout time.Duration) (content []byte, complete bool, err error) {
+	if timeout <= 0 {
+		return readPrefix(path, maxBytes)
+	}
+
+	type read struct {
+		content  []byte
+		complete bool
+		err      error
+	}
+	// The channel is buffered so that a read that outlives the timeout
+	// does not block forever once it ends.
+	done := make(chan read, 1)
+	go func() {
+		content, complete, err := readPrefix(path, maxBytes)
+		done <- read{content, complete, err}
+	}()
+
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+	select {
+	case r := <-done:
+		return r.content, r.complete, r.err
+	case <-timer.C:
+		return nil, false, &skipError{SkipTimeout}
+	}
+}
+
+func readPrefix(path string, maxBytes int64) ([]byte, bool, error) {
+	// Opening a named pipe for reading blocks until there is a writer, so
+	// special files, which may be the target of a symlink, are rejected
+	// before opening them.
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, false, err
+	}
+	if kind := SpecialFileKind(info.Mode()); kind != "" {
+		return nil, false, &skipError{kind}
+	}
+	// The file may still be replaced before it is opened, which O_NONBLOCK
+	// keeps from blocking.
+	f, err := os.OpenFile(path, os.O_RDONLY|syscall.O_NONBLOCK, 0)
+	if err != nil {
+		return nil, false, err
+	}
+	defer f.Close()
+
+	if info, err = f.Stat(); err != nil {
+		return nil, false, err
+	}
+	if kind := SpecialFileKind(info.Mode()); kind != "" {
+		return nil, false, &skipError{kind}
+	}
+	if maxBytes <= 0 || info.Size() <= maxBytes {
+		content, err := io.ReadAll(f)
+		return content, true, err
+	}
+
+	prefix := make([]byte, charset.SniffLen)
+	n, err := io.ReadFull(f, prefix)
+	if err != nil && !errors.Is(err, io.ErrUnexpectedEOF) {
+		return nil, false, err
+	}
+	return prefix[:n], false, nil
+}
@@ -0,0 +1,74 @@
+package processor
+
+import (
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestReadFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "file.txt")
+	data := strings.Repeat("x", 20000)
+	if err := os.WriteFile(path, []byte(data), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	testCases := []struct {
+		name     string
+		maxBytes int64
+		length   int
+		complete bool
+	}{
+		{name: "No limit", maxBytes: 0, length: len(data), complete: true},
+		{name: "Within limit", maxBytes: int64(len(data)), length: len(data), complete: true},
+		{name: "Over limit reads a prefix", maxBytes: 100, length: 8 << 10, complete: false},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			content, complete, err := readFile(path, tc.maxBytes, 0)
+			if err != nil || len(content) != tc.length || complete != tc.complete {
+				t.Errorf("readFile() = %d bytes, %v, %v; want %d bytes, %v, nil", len(content), complete, err, tc.length, tc.complete)
+			}
+		})
+	}
+}
+
+func TestSpecialFileKind(t *testing.T) {
+	testCases := []struct {
+		mode     fs.FileMode
+		expected string
+	}{
+		{mode: 0o644, expected: ""},
+		{mode: fs.ModeDir, expected: ""},
+		{mode: fs.ModeSymlink, expected: ""},
+		{mode: fs.ModeNamedPipe, expected: "named pipe"},
+		{mode: fs.ModeSocket, expected: "socket"},
+		{mode: fs.ModeDevice | fs.ModeCharDevice, expected: "device"},
+		{mode: fs.ModeIrregular, expected: "irregular file"},
+	}
+
+	for _, tc := range testCases {
+		if got := SpecialFileKind(tc.mode); got != tc.expected {
+			t.Errorf("SpecialFileKind(%v) = %q; want %q", tc.mode, got, tc.expected)
+		}
+	}
+}
+
+func TestBundleHeader(t *testing.T) {
+	for _, format := range []string{"", "{n} | ", "L{n} --> "} {
+		header := BundleHeader(format)
+		gotHeader, body, gotFormat, ok := ParseBundleHeader(header + "\n\n# Files\n")
+		if gotHeader != header || body != "\n\n# Files\n" || gotFormat != format || !ok {
+			t.Errorf("ParseBundleHeader(BundleHeader(%q)) = %q, %q, %q, %v; want %q, %q, %q, true", format, gotHeader, body, gotFormat, ok, header, "\n\n# Files\n", format)
+		}
+		if strings.Count(header, "-->") != strings.Count(header, "<!--") {
+			t.Errorf("BundleHeader(%q) = %q; want the format kept inside the comment", format, header)
+		}
+	}
+	if _, _, _, ok := ParseBundleHeader("# Notes\n"); ok {
+		t.Errorf("ParseBundleHeader() of a plain file reported a bundle")
+	}
+}
@@ -0,0 +1,34 @@
+//go:build unix
+
+package processor
+
+import (
+	"errors"
+	"path/filepath"
+	"syscall"
+	"testing"
+	"time"
+)
+
+func TestReadFileSkipsNamedPipes(t *testing.T) {
+	dir := t.TempDir()
+	fifo := filepath.Join(dir, "fifo")
+	if err := syscall.Mkfifo(fifo, 0o644); err != nil {
+		t.Skipf("cannot create a named pipe: %v", err)
+	}
+
+	const timeout = 5 * time.Second
+	start := time.Now()
+	_, _, err := readFile(fifo, 0, timeout)
+	var skip *skipError
+	if !errors.As(err, &skip) || skip.reason != "named pipe" {
+		t.Errorf("readFile() error = %v; want the named pipe to be skipped", err)
+	}
+	if elapsed := time.Since(start); elapsed >= timeout {
+		t.Errorf("readFile() took %v; want it to return before the read timeout", elapsed)
+	}
+
+	if got := ProcessFile(fifo, Options{ReadTimeout: timeout}); got.SkipReason != "named pipe" {
+		t.Errorf("ProcessFile().SkipReason = %q; want %q", got.SkipReason, "named pipe")
+	}
+}
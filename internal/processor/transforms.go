@@ -0,0 +1,147 @@
+package processor
+
+import (
+	"path/filepath"
+	"regexp"
+	"time"
+
+	"github.com/axseem/dirmd/internal/detect"
+	"github.com/axseem/dirmd/internal/tokens"
+	"github.com/axseem/dirmd/internal/transform"
+	"github.com/axseem/dirmd/internal/unicodescan"
+)
+
+// Transform names, as reported in Saving.
+const (
+	TransformNormalizeNewlines = "normalize-newlines"
+	TransformMaskValues        = "mask-values"
+	TransformStripComments     = "strip-comments"
+	TransformTrimTrailing      = "trim-trailing-whitespace"
+	TransformCollapseBlank     = "collapse-blank-lines"
+	TransformIndentTabs        = "indent-tabs"
+)
+
+// Options controls the transforms applied to file contents.
+type Options struct {
+	// MaxReadBytes is the size above which files are skipped, once a prefix
+	// has been read to tell whether they are binary. Zero means no limit.
+	MaxReadBytes int64
+	// MaxLines is the number of lines above which files are skipped. Zero
+	// means no limit.
+	MaxLines int
+	// ReadTimeout is how long reading a file may take. Zero means no limit.
+	ReadTimeout time.Duration
+	// Language overrides the detected language of the file, such as with the
+	// linguist-language attribute. It is normalized to a Markdown identifier.
+	Language string
+	// Languages maps lowercase file names and extensions to languages. It
+	// takes precedence over modelines and the built-in mappings.
+	Languages map[string]string
+	// UnicodeCheck decides whether suspicious Unicode characters are reported
+	// or escaped. The empty mode disables the check.
+	UnicodeCheck unicodescan.Mode
+	// Scan, if set, is called with the decoded content of the file, its
+	// language and its category before any transform. It returns the issues
+	// it found, on lines of the file, and the content to process in place of
+	// the original, such as with secrets redacted.
+	Scan func(content []byte, lang string, category detect.Category) ([]Finding, []byte)
+	// NormalizeNewlines converts CRLF line endings to LF.
+	NormalizeNewlines bool
+	// MaskValues replaces the values of configuration files with placeholders
+	// describing their type, keeping their keys and structure.
+	MaskValues bool
+	// StripComments removes comments from files in languages with known comment syntax.
+	StripComments bool
+	// KeepDocComments keeps documentation comments when stripping comments.
+	KeepDocComments bool
+	// TrimTrailingWhitespace removes blanks at the end of lines, except in
+	// languages where they are significant.
+	TrimTrailingWhitespace bool
+	// CollapseBlankLines replaces runs of blank lines with a single one.
+	CollapseBlankLines bool
+	// IndentWithTabs rewrites leading spaces as tabs of TabWidth columns,
+	// except in languages where indentation is significant.
+	IndentWithTabs bool
+	// TabWidth is the number of columns one level of indentation spans.
+	TabWidth int
+	// Grep skips the files whose content it does not match once all
+	// transforms have run.
+	Grep *regexp.Regexp
+	// GrepContext is the number of lines kept around each line Grep matches,
+	// with the other lines left out. Negative values keep whole files.
+	GrepContext int
+	// Lines lists the lines to mark, such as those a stack trace refers to,
+	// numbered as in the file. Transforms that remove lines are not applied
+	// to files with marked lines.
+	Lines []int
+	// LinesContext is the number of lines kept around each line in Lines,
+	// with the other lines left out. Negative values keep whole files.
+	LinesContext int
+	// Limits bounds the size of the content once all transforms have run.
+	Limits transform.Limits
+	// LineNumbers prefixes every line with its line number in the file once
+	// all transforms have run, even if they removed or joined lines.
+	LineNumbers bool
+	// LineNumberFormat is the line number prefix, see transform.NumberLines.
+	LineNumberFormat string
+	// LineNumbersRelative numbers the lines kept from a truncated file from 1
+	// instead of by their position in the file.
+	LineNumbersRelative bool
+}
+
+// Saving is the number of bytes and estimated tokens a transform removed from a file.
+type Saving struct {
+	Transform string `json:"transform"`
+	Bytes     int    `json:"bytes"`
+	Tokens    int    `json:"tokens"`
+}
+
+// applyTransforms runs the transforms enabled in opts over content in order
+// and records what each of them saved. The LineMap tells which line of the
+// file each line of the result comes from.
+func applyTransforms(path string, content []byte, lang string, opts Options) ([]byte, []Saving, transform.LineMap) {
+	format, isConfig := transform.DetectConfigFormat(filepath.ToSlash(path), lang)
+
+	steps := []struct {
+		name    string
+		enabled bool
+		apply   func([]byte) ([]byte, transform.LineMap)
+	}{
+		{TransformNormalizeNewlines, opts.NormalizeNewlines, keepLines(transform.NormalizeNewlines)},
+		{TransformMaskValues, opts.MaskValues && isConfig, func(src []byte) ([]byte, transform.LineMap) {
+			return transform.MaskValues(src, format)
+		}},
+		{TransformStripComments, opts.StripComments, func(src []byte) ([]byte, transform.LineMap) {
+			return transform.StripComments(src, lang, opts.KeepDocComments)
+		}},
+		{TransformTrimTrailing, opts.TrimTrailingWhitespace && !transform.IsTrailingWhitespaceSensitive(lang), keepLines(transform.TrimTrailingWhitespace)},
+		{TransformCollapseBlank, opts.CollapseBlankLines, transform.CollapseBlankLines},
+		{TransformIndentTabs, opts.IndentWithTabs && !transform.IsIndentationSensitive(lang), keepLines(func(src []byte) []byte {
+			return transform.IndentWithTabs(src, opts.TabWidth, lang)
+		})},
+	}
+
+	var savings []Saving
+	var lines transform.LineMap
+	for _, step := range steps {
+		if !step.enabled {
+			continue
+		}
+		transformed, stepLines := step.apply(content)
+		lines = lines.Then(stepLines)
+		savings = append(savings, Saving{
+			Transform: step.name,
+			Bytes:     len(content) - len(transformed),
+			Tokens:    tokens.Estimate(content) - tokens.Estimate(transformed),
+		})
+		content = transformed
+	}
+	return content, savings, lines
+}
+
+// keepLines adapts a transform that keeps every line in place.
+func keepLines(apply func([]byte) []byte) func([]byte) ([]byte, transform.LineMap) {
+	return func(src []byte) ([]byte, transform.LineMap) {
+		return apply(src), nil
+	}
+}
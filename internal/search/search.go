@@ -0,0 +1,176 @@
+// Package search ranks documents against a free-text query with BM25.
+package search
+
+import (
+	"cmp"
+	"math"
+	"slices"
+	"strings"
+	"unicode"
+)
+
+// BM25 parameters, at their usual values.
+const (
+	k1 = 1.2
+	b  = 0.75
+)
+
+// pathWeight is how many times the terms of a document's name count, as
+// names tend to say more about a file than any single line of it.
+const pathWeight = 3
+
+// stopWords are common English words that carry no meaning in a query.
+var stopWords = map[string]bool{
+	"a": true, "an": true, "and": true, "are": true, "as": true, "at": true,
+	"be": true, "by": true, "do": true, "does": true, "for": true, "from": true,
+	"how": true, "in": true, "is": true, "it": true, "of": true, "on": true,
+	"or": true, "that": true, "the": true, "this": true, "to": true,
+	"what": true, "when": true, "where": true, "which": true, "why": true,
+	"with": true,
+}
+
+// Tokenize splits text into lowercase terms. Identifiers are split at
+// camelCase and snake_case boundaries and also kept whole, digits-only
+// words and stop words are dropped, and plurals are reduced to their
+// singular, so that "retryPolicies" yields "retry", "policy" and
+// "retrypolicy".
+func Tokenize(text string) []string {
+	var terms []string
+	add := func(word string) {
+		if term := normalize(word); len(term) > 1 && !stopWords[term] {
+			terms = append(terms, term)
+		}
+	}
+	words := strings.FieldsFunc(text, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r) && r != '_'
+	})
+	for _, word := range words {
+		parts := splitIdentifier(word)
+		for _, part := range parts {
+			add(part)
+		}
+		if len(parts) > 1 {
+			add(strings.ReplaceAll(word, "_", ""))
+		}
+	}
+	return terms
+}
+
+// splitIdentifier splits word at underscores and case changes, keeping
+// acronyms together: "parseHTTPRequest_v2" yields "parse", "HTTP",
+// "Request" and "v2".
+func splitIdentifier(word string) []string {
+	var parts []string
+	for chunk := range strings.SplitSeq(word, "_") {
+		runes := []rune(chunk)
+		start := 0
+		for i := 1; i < len(runes); i++ {
+			lowerToUpper := unicode.IsLower(runes[i-1]) && unicode.IsUpper(runes[i])
+			acronymEnd := unicode.IsUpper(runes[i-1]) && unicode.IsUpper(runes[i]) && i+1 < len(runes) && unicode.IsLower(runes[i+1])
+			if lowerToUpper || acronymEnd {
+				parts = append(parts, string(runes[start:i]))
+				start = i
+			}
+		}
+		if start < len(runes) {
+			parts = append(parts, string(runes[start:]))
+		}
+	}
+	return parts
+}
+
+// normalize lowercases word and reduces common plural endings. Words made
+// only of digits are dropped.
+func normalize(word string) string {
+	if strings.IndexFunc(word, func(r rune) bool { return !unicode.IsDigit(r) }) < 0 {
+		return ""
+	}
+	word = strings.ToLower(word)
+	switch {
+	case len(word) > 4 && strings.HasSuffix(word, "ies"):
+		return word[:len(word)-3] + "y"
+	case len(word) > 3 && strings.HasSuffix(word, "s") && !strings.HasSuffix(word, "ss") && !strings.HasSuffix(word, "us"):
+		return word[:len(word)-1]
+	}
+	return word
+}
+
+// Index holds the term statistics of a set of documents.
+type Index struct {
+	docs []document
+	// docFreq counts the documents each term appears in.
+	docFreq map[string]int
+	// totalLen is the sum of the lengths of all documents, in terms.
+	totalLen int
+}
+
+type document struct {
+	name  string
+	freq  map[string]int
+	terms int
+}
+
+// NewIndex creates an empty index.
+func NewIndex() *Index {
+	return &Index{docFreq: make(map[string]int)}
+}
+
+// Add indexes a document under its name, such as a file path, which is
+// searched along with its content.
+func (idx *Index) Add(name, content string) {
+	doc := document{name: name, freq: make(map[string]int)}
+	for _, term := range Tokenize(name) {
+		doc.freq[term] += pathWeight
+		doc.terms += pathWeight
+	}
+	for _, term := range Tokenize(content) {
+		doc.freq[term]++
+		doc.terms++
+	}
+	for term := range doc.freq {
+		idx.docFreq[term]++
+	}
+	idx.totalLen += doc.terms
+	idx.docs = append(idx.docs, doc)
+}
+
+// Result is a document matching a query.
+type Result struct {
+	Name  string
+	Score float64
+}
+
+// Search returns the documents matching query, the most relevant first.
+// Documents with equal scores are ordered by name.
+func (idx *Index) Search(query string) []Result {
+	if len(idx.docs) == 0 {
+		return nil
+	}
+	terms := slices.Compact(slices.Sorted(slices.Values(Tokenize(query))))
+	avgLen := float64(idx.totalLen) / float64(len(idx.docs))
+	n := float64(len(idx.docs))
+
+	var results []Result
+	for _, doc := range idx.docs {
+		score := 0.0
+		for _, term := range terms {
+			tf := float64(doc.freq[term])
+			if tf == 0 {
+				continue
+			}
+			df := float64(idx.docFreq[term])
+			idf := math.Log(1 + (n-df+0.5)/(df+0.5))
+			score += idf * tf * (k1 + 1) / (tf + k1*(1-b+b*float64(doc.terms)/avgLen))
+		}
+		if score > 0 {
+			results = append(results, Result{Name: doc.name, Score: score})
+		}
+	}
+	slices.SortFunc(results, func(x, y Result) int {
+		if c := cmp.Compare(y.Score, x.Score); c != 0 {
+			return c
+		}
+		return strings.Compare(x.Name, y.Name)
+	})
+	return results
+}
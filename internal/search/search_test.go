@@ -0,0 +1,53 @@
+package search
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestTokenize(t *testing.T) {
+	testCases := []struct {
+		text     string
+		expected []string
+	}{
+		{text: "retryPolicies", expected: []string{"retry", "policy", "retrypolicy"}},
+		{text: "max_retry_count", expected: []string{"max", "retry", "count", "maxretrycount"}},
+		{text: "parseHTTPRequest", expected: []string{"parse", "http", "request", "parsehttprequest"}},
+		{text: "How are retries configured?", expected: []string{"retry", "configured"}},
+		{text: "internal/config/file.go:42", expected: []string{"internal", "config", "file", "go"}},
+		{text: "status class x 2024", expected: []string{"status", "class"}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.text, func(t *testing.T) {
+			if got := Tokenize(tc.text); !reflect.DeepEqual(got, tc.expected) {
+				t.Errorf("Tokenize(%q) = %q; want %q", tc.text, got, tc.expected)
+			}
+		})
+	}
+}
+
+func TestSearch(t *testing.T) {
+	idx := NewIndex()
+	idx.Add("internal/retry/policy.go", "type RetryPolicy struct { MaxRetries int; Backoff time.Duration }")
+	idx.Add("internal/client/client.go", "func (c *Client) Do(req *Request) { for i := 0; i < c.policy.MaxRetries; i++ {} }")
+	idx.Add("README.md", "A client library for HTTP services.")
+	idx.Add("main.go", "func main() { run() }")
+
+	results := idx.Search("how are retries configured")
+	var names []string
+	for _, r := range results {
+		names = append(names, r.Name)
+	}
+	expected := []string{"internal/retry/policy.go", "internal/client/client.go"}
+	if !reflect.DeepEqual(names, expected) {
+		t.Errorf("Search() = %v; want %v", names, expected)
+	}
+	if results[0].Score <= results[1].Score {
+		t.Errorf("Search() scores = %v; want decreasing scores", results)
+	}
+
+	if got := idx.Search("the"); got != nil {
+		t.Errorf("Search() of a stop word = %v; want no results", got)
+	}
+}
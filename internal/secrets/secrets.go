@@ -0,0 +1,253 @@
+// Package secrets finds and redacts credentials in file contents.
+package secrets
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"math"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+
+	ignore "github.com/sabhiram/go-gitignore"
+)
+
+// Mode decides what happens when a secret is found.
+type Mode string
+
+const (
+	// ModeOff disables secret scanning.
+	ModeOff Mode = "off"
+	// ModeWarn reports secrets but bundles them unchanged.
+	ModeWarn Mode = "warn"
+	// ModeRedact replaces secrets with a placeholder naming their type.
+	ModeRedact Mode = "redact"
+	// ModeAbort fails the run if any secret is found.
+	ModeAbort Mode = "abort"
+)
+
+// ParseMode validates the name of a scanning mode.
+func ParseMode(name string) (Mode, error) {
+	switch m := Mode(name); m {
+	case ModeOff, ModeWarn, ModeRedact, ModeAbort:
+		return m, nil
+	}
+	return "", fmt.Errorf("unknown secrets mode %q (want %s, %s, %s or %s)", name, ModeOff, ModeWarn, ModeRedact, ModeAbort)
+}
+
+// rule describes a credential format. If the pattern has capturing groups,
+// only the first group that matched is the secret, otherwise the whole
+// match is.
+type rule struct {
+	name    string
+	pattern *regexp.Regexp
+	// minEntropy, if set, is the Shannon entropy in bits per character
+	// the secret must reach, which filters out placeholders and words.
+	minEntropy float64
+	// codePattern, if set, replaces pattern in source code.
+	codePattern *regexp.Regexp
+	// notSecret, if set, matches values in source code that are not secrets
+	// even though the pattern matched them, such as names and expressions.
+	notSecret *regexp.Regexp
+}
+
+// genericSecret matches the keywords credentials are assigned to, followed
+// by an assignment.
+const genericSecret = `(?i)[A-Za-z0-9_.-]*(?:secret|token|passw(?:or)?d|api_?key|access_?key|credential)[A-Za-z0-9_.-]*["']?\s*(?::=|[:=])\s*`
+
+var rules = []rule{
+	{name: "private-key", pattern: regexp.MustCompile(`-----BEGIN[A-Z ]*PRIVATE KEY(?: BLOCK)?-----[\s\S]*?-----END[A-Z ]*PRIVATE KEY(?: BLOCK)?-----`)},
+	{name: "aws-access-key-id", pattern: regexp.MustCompile(`\b(?:AKIA|ASIA|AGPA|AIDA|AROA|AIPA|ANPA|ANVA)[A-Z0-9]{16}\b`)},
+	{name: "aws-secret-access-key", pattern: regexp.MustCompile(`(?i)aws_?secret_?access_?key["']?\s*[:=]\s*["']?([A-Za-z0-9/+=]{40})\b`)},
+	{name: "github-token", pattern: regexp.MustCompile(`\b(?:gh[pousr]_[A-Za-z0-9]{36,}|github_pat_[A-Za-z0-9_]{22,})\b`)},
+	{name: "gitlab-token", pattern: regexp.MustCompile(`\bglpat-[A-Za-z0-9_-]{20,}\b`)},
+	{name: "slack-token", pattern: regexp.MustCompile(`\bxox[abposr]-[A-Za-z0-9-]{10,}\b`)},
+	{name: "stripe-key", pattern: regexp.MustCompile(`\b[rs]k_live_[A-Za-z0-9]{16,}\b`)},
+	{name: "google-api-key", pattern: regexp.MustCompile(`\bAIza[A-Za-z0-9_-]{35}\b`)},
+	{name: "jwt", pattern: regexp.MustCompile(`\beyJ[A-Za-z0-9_-]{8,}\.eyJ[A-Za-z0-9_-]{8,}\.[A-Za-z0-9_-]{8,}`)},
+	{
+		name: "generic-secret",
+		// Values may be quoted or not, as in .env and YAML files.
+		pattern:    regexp.MustCompile(genericSecret + `(?:["'` + "`" + `]([^\s"'` + "`" + `]{16,})["'` + "`" + `]|([^\s"'` + "`" + `,;]{16,}))`),
+		minEntropy: 3.5,
+		// Only quoted values count in code: unquoted ones are mostly expressions.
+		codePattern: regexp.MustCompile(genericSecret + `["'` + "`" + `]([^\s"'` + "`" + `]{16,})["'` + "`" + `]`),
+		// Words without digits, selector expressions, calls and URLs.
+		notSecret: regexp.MustCompile(`^(?:[A-Za-z_]+|[A-Za-z_]\w*(?:\.[A-Za-z_]\w*)+\(?|[A-Za-z_][\w.]*\(.*|\w+://.*)$`),
+	},
+}
+
+// Finding is a secret found in a file.
+type Finding struct {
+	Rule string
+	// Line is the line of the content the secret starts on, counting from 1.
+	Line int
+	// Start and End are the byte offsets of the secret in the content.
+	Start, End int
+}
+
+// Scanner finds secrets, skipping those matched by an allowlist.
+type Scanner struct {
+	allowValues []*regexp.Regexp
+	allowPaths  *ignore.GitIgnore
+}
+
+// NewScanner creates a Scanner. If allowlistPath is not empty, the file
+// is read as an allowlist: lines starting with "path:" hold .gitignore-style
+// patterns of files that are never scanned, and every other line is a
+// regular expression matched against the text of each secret. Blank lines
+// and lines starting with # are ignored.
+func NewScanner(allowlistPath string) (*Scanner, error) {
+	s := &Scanner{allowPaths: ignore.CompileIgnoreLines()}
+	if allowlistPath == "" {
+		return s, nil
+	}
+
+	file, err := os.Open(allowlistPath)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	var pathPatterns []string
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		if pattern, ok := strings.CutPrefix(line, "path:"); ok {
+			pathPatterns = append(pathPatterns, strings.TrimSpace(pattern))
+			continue
+		}
+		re, err := regexp.Compile(line)
+		if err != nil {
+			return nil, fmt.Errorf("invalid allowlist pattern %q: %w", line, err)
+		}
+		s.allowValues = append(s.allowValues, re)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	s.allowPaths = ignore.CompileIgnoreLines(pathPatterns...)
+	return s, nil
+}
+
+// codeLanguages lists the programming languages, as opposed to data and
+// configuration formats, whose assignments are mostly expressions.
+var codeLanguages = map[string]bool{
+	"c": true, "clojure": true, "coffeescript": true, "cpp": true, "csharp": true,
+	"d": true, "dart": true, "elixir": true, "elm": true, "erlang": true,
+	"fsharp": true, "go": true, "groovy": true, "haskell": true, "java": true,
+	"javascript": true, "jsx": true, "julia": true, "kotlin": true, "lua": true,
+	"objectivec": true, "ocaml": true, "perl": true, "php": true, "python": true,
+	"r": true, "ruby": true, "rust": true, "scala": true, "svelte": true,
+	"swift": true, "tsx": true, "typescript": true, "vbnet": true, "vue": true,
+	"zig": true,
+}
+
+// Scan returns the secrets in content, written in lang, ordered by position.
+// relPath is the slash-separated path of the file relative to the root, used
+// by the allowlist.
+func (s *Scanner) Scan(relPath, lang string, content []byte) []Finding {
+	if s.allowPaths.MatchesPath(relPath) {
+		return nil
+	}
+
+	code := codeLanguages[lang]
+	var findings []Finding
+	for _, r := range rules {
+		pattern := r.pattern
+		if code && r.codePattern != nil {
+			pattern = r.codePattern
+		}
+		for _, m := range pattern.FindAllSubmatchIndex(content, -1) {
+			start, end := m[0], m[1]
+			for i := 2; i < len(m); i += 2 {
+				if m[i] >= 0 {
+					start, end = m[i], m[i+1]
+					break
+				}
+			}
+			secret := content[start:end]
+			if r.minEntropy > 0 && entropy(secret) < r.minEntropy {
+				continue
+			}
+			if code && r.notSecret != nil && r.notSecret.Match(secret) {
+				continue
+			}
+			if s.allowed(secret) || overlaps(findings, start, end) {
+				continue
+			}
+			findings = append(findings, Finding{
+				Rule:  r.name,
+				Line:  bytes.Count(content[:start], []byte("\n")) + 1,
+				Start: start,
+				End:   end,
+			})
+		}
+	}
+	sort.Slice(findings, func(i, j int) bool { return findings[i].Start < findings[j].Start })
+	return findings
+}
+
+func (s *Scanner) allowed(secret []byte) bool {
+	for _, re := range s.allowValues {
+		if re.Match(secret) {
+			return true
+		}
+	}
+	return false
+}
+
+// overlaps reports whether the range start:end overlaps an earlier finding,
+// so that a secret matched by a specific rule is not reported again by a
+// generic one.
+func overlaps(findings []Finding, start, end int) bool {
+	for _, f := range findings {
+		if start < f.End && f.Start < end {
+			return true
+		}
+	}
+	return false
+}
+
+// Placeholder returns the text that replaces a secret found by rule.
+func Placeholder(rule string) string {
+	return "<redacted:" + rule + ">"
+}
+
+// Redact replaces every finding in content with its placeholder.
+// findings must be ordered by position, as returned by Scan.
+func Redact(content []byte, findings []Finding) []byte {
+	if len(findings) == 0 {
+		return content
+	}
+	out := make([]byte, 0, len(content))
+	prev := 0
+	for _, f := range findings {
+		out = append(out, content[prev:f.Start]...)
+		out = append(out, Placeholder(f.Rule)...)
+		prev = f.End
+	}
+	return append(out, content[prev:]...)
+}
+
+// entropy returns the Shannon entropy of data in bits per byte.
+func entropy(data []byte) float64 {
+	var counts [256]int
+	for _, c := range data {
+		counts[c]++
+	}
+	var h float64
+	for _, n := range counts {
+		if n == 0 {
+			continue
+		}
+		p := float64(n) / float64(len(data))
+		h -= p * math.Log2(p)
+	}
+	return h
+}
@@ -0,0 +1,56 @@
+// Package tokens estimates how many LLM tokens a piece of text occupies.
+package tokens
+
+import (
+	"unicode"
+	"unicode/utf8"
+)
+
+// bytesPerToken is the typical number of bytes a tokenizer merges into a
+// single token within a run of letters or digits.
+const bytesPerToken = 4
+
+// Estimate approximates the number of tokens data occupies with common
+// byte-pair encoding tokenizers, without depending on any particular one.
+// Words count one token per four bytes, punctuation one token per
+// character, a single space is absorbed by the following word, and longer
+// runs of blanks count like words.
+func Estimate(data []byte) int {
+	count := 0
+	for i := 0; i < len(data); {
+		r, size := utf8.DecodeRune(data[i:])
+		switch {
+		case r == '\n':
+			count++
+			i += size
+		case r == ' ' || r == '\t' || r == '\r':
+			j := i
+			for j < len(data) && (data[j] == ' ' || data[j] == '\t' || data[j] == '\r') {
+				j++
+			}
+			if j-i > 1 {
+				count += ceilDiv(j-i, bytesPerToken)
+			}
+			i = j
+		case unicode.IsLetter(r) || unicode.IsDigit(r) || r == '_':
+			j := i
+			for j < len(data) {
+				r, size := utf8.DecodeRune(data[j:])
+				if !unicode.IsLetter(r) && !unicode.IsDigit(r) && r != '_' {
+					break
+				}
+				j += size
+			}
+			count += ceilDiv(j-i, bytesPerToken)
+			i = j
+		default:
+			count++
+			i += size
+		}
+	}
+	return count
+}
+
+func ceilDiv(a, b int) int {
+	return (a + b - 1) / b
+}
@@ -0,0 +1,53 @@
+package tokens
+
+import (
+	"testing"
+)
+
+func TestEstimate(t *testing.T) {
+	testCases := []struct {
+		name     string
+		data     string
+		expected int
+	}{
+		{
+			name:     "Empty",
+			data:     "",
+			expected: 0,
+		},
+		{
+			name:     "Short words",
+			data:     "hello world",
+			expected: 4,
+		},
+		{
+			name:     "Punctuation",
+			data:     "f(x);",
+			expected: 5,
+		},
+		{
+			name:     "Indentation and newline",
+			data:     "\n        return",
+			expected: 5,
+		},
+		{
+			name:     "Tab indentation",
+			data:     "\treturn",
+			expected: 2,
+		},
+		{
+			name:     "Multibyte letters",
+			data:     "こんにちは",
+			expected: 4,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			got := Estimate([]byte(tc.data))
+			if got != tc.expected {
+				t.Errorf("Estimate(%q) = %d; want %d", tc.data, got, tc.expected)
+			}
+		})
+	}
+}
@@ -0,0 +1,96 @@
+// Package trace finds the source locations referred to by stack traces and
+// compiler diagnostics.
+package trace
+
+import (
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// Reference is a source location mentioned in a trace.
+type Reference struct {
+	Path string
+	// Line is the referenced line, counting from 1.
+	Line int
+	// Column is the referenced column, or 0 if the trace gives none.
+	Column int
+}
+
+var (
+	// pythonFrame matches the frames of Python tracebacks:
+	//   File "/app/main.py", line 12, in main
+	pythonFrame = regexp.MustCompile(`File "([^"]+)", line (\d+)`)
+	// location matches path:line[:col], as found in Go panics, JavaScript
+	// stack traces and compiler diagnostics:
+	//   /app/main.go:42 +0x1d
+	//   at run (file:///app/index.js:12:5)
+	//   ./main.go:12:5: undefined: x
+	location = regexp.MustCompile(`(?:^|[\s(\[])(?:file://)?((?:[A-Za-z]:)?[^\s:()"'\[\]]+\.\w+):(\d+)(?::(\d+))?`)
+)
+
+// Parse returns the references in text, in the order they appear, without
+// duplicates.
+func Parse(text string) []Reference {
+	var refs []Reference
+	add := func(path, line, column string) {
+		ref := Reference{Path: path}
+		ref.Line, _ = strconv.Atoi(line)
+		ref.Column, _ = strconv.Atoi(column)
+		if ref.Line > 0 && !slices.Contains(refs, ref) {
+			refs = append(refs, ref)
+		}
+	}
+	for line := range strings.Lines(text) {
+		if m := pythonFrame.FindStringSubmatch(line); m != nil {
+			add(m[1], m[2], "")
+			continue
+		}
+		for _, m := range location.FindAllStringSubmatch(line, -1) {
+			add(m[1], m[2], m[3])
+		}
+	}
+	return refs
+}
+
+// Locate returns the file under root that path refers to. Paths recorded on
+// other machines or relative to other directories are matched by their
+// longest suffix naming a file under root. The suffix must have at least two
+// parts, such as pkg/main.go, unless it is the whole relative path, since a
+// bare file name is likely to match an unrelated file.
+func Locate(root, path string) (string, bool) {
+	path = filepath.Clean(filepath.FromSlash(strings.ReplaceAll(path, `\`, "/")))
+	if filepath.IsAbs(path) && isWithin(root, path) && isFile(path) {
+		return path, true
+	}
+	parts := strings.Split(strings.TrimPrefix(filepath.ToSlash(path), "/"), "/")
+	whole := !filepath.IsAbs(path)
+	// Parts up to the last ".." lead out of the directory the path is relative to.
+	for i := slices.Index(parts, ".."); i >= 0; i = slices.Index(parts, "..") {
+		parts = parts[i+1:]
+		whole = false
+	}
+	for i := range parts {
+		if len(parts)-i < 2 && !(i == 0 && whole) {
+			break
+		}
+		candidate := filepath.Join(root, filepath.Join(parts[i:]...))
+		if isFile(candidate) {
+			return candidate, true
+		}
+	}
+	return "", false
+}
+
+func isWithin(root, path string) bool {
+	rel, err := filepath.Rel(root, path)
+	return err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
+
+func isFile(path string) bool {
+	info, err := os.Stat(path)
+	return err == nil && info.Mode().IsRegular()
+}
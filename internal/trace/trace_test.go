@@ -0,0 +1,114 @@
+package trace
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	testCases := []struct {
+		name     string
+		text     string
+		expected []Reference
+	}{
+		{
+			name: "Go panic",
+			text: `panic: runtime error: index out of range [3] with length 3
+
+goroutine 1 [running]:
+main.lookup(...)
+	/home/ci/app/internal/store/store.go:42
+main.main()
+	/home/ci/app/main.go:17 +0x1d
+exit status 2
+`,
+			expected: []Reference{{Path: "/home/ci/app/internal/store/store.go", Line: 42}, {Path: "/home/ci/app/main.go", Line: 17}},
+		},
+		{
+			name: "Go build and vet diagnostics",
+			text: `# example.com/app
+./main.go:12:5: undefined: x
+./main.go:12:5: undefined: x
+internal/store/store.go:8:2: "fmt" imported and not used
+`,
+			expected: []Reference{{Path: "./main.go", Line: 12, Column: 5}, {Path: "internal/store/store.go", Line: 8, Column: 2}},
+		},
+		{
+			name: "Python traceback",
+			text: `Traceback (most recent call last):
+  File "/srv/app/main.py", line 12, in <module>
+    run()
+  File "app/jobs.py", line 3, in run
+ZeroDivisionError: division by zero
+`,
+			expected: []Reference{{Path: "/srv/app/main.py", Line: 12}, {Path: "app/jobs.py", Line: 3}},
+		},
+		{
+			name: "JavaScript stack",
+			text: `TypeError: Cannot read properties of undefined (reading 'id')
+    at render (file:///srv/app/src/view.js:27:14)
+    at /srv/app/src/index.js:5:3
+    at node:internal/main/run_main_module:28:49
+`,
+			expected: []Reference{{Path: "/srv/app/src/view.js", Line: 27, Column: 14}, {Path: "/srv/app/src/index.js", Line: 5, Column: 3}},
+		},
+		{
+			name: "No references",
+			text: "listening on http://localhost:8080\n",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := Parse(tc.text); !reflect.DeepEqual(got, tc.expected) {
+				t.Errorf("Parse() = %+v; want %+v", got, tc.expected)
+			}
+		})
+	}
+}
+
+func TestLocate(t *testing.T) {
+	root := t.TempDir()
+	for _, name := range []string{"main.go", "server.go", "internal/store/store.go"} {
+		path := filepath.Join(root, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, nil, 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	testCases := []struct {
+		path     string
+		expected string
+	}{
+		{path: filepath.Join(root, "main.go"), expected: "main.go"},
+		{path: "./main.go", expected: "main.go"},
+		{path: "/home/ci/app/internal/store/store.go", expected: "internal/store/store.go"},
+		{path: `C:\work\app\internal\store\store.go`, expected: "internal/store/store.go"},
+		{path: "../../internal/store/store.go", expected: "internal/store/store.go"},
+		{path: "/usr/local/go/src/runtime/panic.go"},
+		{path: "/usr/local/go/src/net/http/server.go"},
+		{path: "/home/ci/app/main.go"},
+		{path: "../main.go"},
+		{path: "internal"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.path, func(t *testing.T) {
+			got, ok := Locate(root, tc.path)
+			if tc.expected == "" {
+				if ok {
+					t.Errorf("Locate(%q) = %q; want no file", tc.path, got)
+				}
+				return
+			}
+			if expected := filepath.Join(root, tc.expected); got != expected || !ok {
+				t.Errorf("Locate(%q) = %q, %v; want %q", tc.path, got, ok, expected)
+			}
+		})
+	}
+}
@@ -0,0 +1,986 @@
+// Package transform rewrites file contents before they are bundled.
+package transform
+
+import (
+	"bytes"
+	"unicode/utf8"
+)
+
+// commentPosition restricts where a line comment marker is recognised.
+type commentPosition int
+
+const (
+	// anywhere recognises the marker at any position outside of literals.
+	anywhere commentPosition = iota
+	// wordStart recognises the marker only at the start of a line or after whitespace.
+	wordStart
+	// lineStart recognises the marker only when it is the first non-blank text on a line.
+	lineStart
+)
+
+// quote describes a string literal delimiter.
+type quote struct {
+	open, close string
+	// escape reports whether a backslash escapes the next character.
+	escape bool
+	// multiline reports whether the literal may span several lines.
+	multiline bool
+	// char marks a character literal delimiter that may also appear
+	// unpaired, such as a Rust lifetime or a C++ digit separator.
+	char bool
+}
+
+// syntax describes the comment and literal rules of a language family.
+type syntax struct {
+	lineComments  []string
+	linePosition  commentPosition
+	blockComments [][2]string
+	nestedBlocks  bool
+	quotes        []quote
+	// rawString returns the end of a raw string literal starting at i, or -1.
+	rawString func(src []byte, i int) int
+	// escapeOutside makes a backslash outside of literals escape the next character.
+	escapeOutside bool
+	// directives are comment prefixes that carry meaning and are always kept.
+	directives []string
+	// docLines and docBlocks are comment prefixes treated as documentation.
+	docLines  []string
+	docBlocks []string
+	// docFollowers marks a whole-line comment group as documentation when
+	// the line directly after it starts with one of these prefixes.
+	docFollowers []string
+	// heredocs enables shell here-documents such as <<EOF and <<-'EOF'.
+	heredocs bool
+}
+
+var (
+	doubleQuote        = quote{open: `"`, close: `"`, escape: true}
+	doubleQuoteMulti   = quote{open: `"`, close: `"`, escape: true, multiline: true}
+	singleQuote        = quote{open: `'`, close: `'`, escape: true}
+	singleQuoteMulti   = quote{open: `'`, close: `'`, escape: true, multiline: true}
+	charQuote          = quote{open: `'`, close: `'`, escape: true, char: true}
+	tripleDoubleQuote  = quote{open: `"""`, close: `"""`, escape: true, multiline: true}
+	tripleSingleQuote  = quote{open: `'''`, close: `'''`, escape: true, multiline: true}
+	backtickTemplate   = quote{open: "`", close: "`", escape: true, multiline: true}
+	backtickRaw        = quote{open: "`", close: "`", multiline: true}
+	cBlock             = [][2]string{{"/*", "*/"}}
+	cDocLines          = []string{"///", "//!"}
+	cDocBlocks         = []string{"/**", "/*!"}
+	hashDirectives     = []string{"#!"}
+	pythonDirectives   = []string{"#!", "# -*-", "# vim:", "# type:"}
+	goDirectives       = []string{"//go:", "// +build", "//export ", "//line "}
+	goDeclarations     = []string{"func ", "type ", "var ", "const ", "package "}
+	tsDirectives       = []string{"/// <reference", "/// <amd"}
+	dockerDirectives   = []string{"# syntax=", "# escape=", "# check="}
+	htmlBlock          = [][2]string{{"<!--", "-->"}}
+	lispBlock          = [][2]string{{"#|", "|#"}}
+	sqlSingleQuote     = quote{open: `'`, close: `'`, multiline: true}
+	sqlDoubleQuote     = quote{open: `"`, close: `"`, multiline: true}
+	shellSingleQuote   = quote{open: `'`, close: `'`, multiline: true}
+	yamlSingleQuote    = quote{open: `'`, close: `'`}
+	powershellVerbatim = quote{open: `'`, close: `'`, multiline: true}
+)
+
+var (
+	goSyntax = &syntax{
+		lineComments:  []string{"//"},
+		blockComments: cBlock,
+		quotes:        []quote{doubleQuote, charQuote, backtickRaw},
+		directives:    goDirectives,
+		docFollowers:  goDeclarations,
+	}
+	cSyntax = &syntax{
+		lineComments:  []string{"//"},
+		blockComments: cBlock,
+		quotes:        []quote{doubleQuote, charQuote},
+		rawString:     cppRawString,
+		docLines:      cDocLines,
+		docBlocks:     cDocBlocks,
+	}
+	javaSyntax = &syntax{
+		lineComments:  []string{"//"},
+		blockComments: cBlock,
+		quotes:        []quote{tripleDoubleQuote, doubleQuote, charQuote},
+		docBlocks:     cDocBlocks,
+	}
+	nestedJavaSyntax = &syntax{
+		lineComments:  []string{"//"},
+		blockComments: cBlock,
+		nestedBlocks:  true,
+		quotes:        []quote{tripleDoubleQuote, doubleQuote, charQuote},
+		docLines:      cDocLines,
+		docBlocks:     cDocBlocks,
+	}
+	dartSyntax = &syntax{
+		lineComments:  []string{"//"},
+		blockComments: cBlock,
+		nestedBlocks:  true,
+		quotes:        []quote{tripleDoubleQuote, tripleSingleQuote, doubleQuote, singleQuote},
+		docLines:      cDocLines,
+		docBlocks:     cDocBlocks,
+	}
+	groovySyntax = &syntax{
+		lineComments:  []string{"//"},
+		blockComments: cBlock,
+		quotes:        []quote{tripleDoubleQuote, tripleSingleQuote, doubleQuote, singleQuote},
+		docBlocks:     cDocBlocks,
+	}
+	csharpSyntax = &syntax{
+		lineComments:  []string{"//"},
+		blockComments: cBlock,
+		quotes: []quote{
+			{open: `"""`, close: `"""`, multiline: true},
+			{open: `@"`, close: `"`, multiline: true},
+			doubleQuote,
+			charQuote,
+		},
+		docLines:  cDocLines,
+		docBlocks: cDocBlocks,
+	}
+	jsSyntax = &syntax{
+		lineComments:  []string{"//"},
+		blockComments: cBlock,
+		quotes:        []quote{doubleQuote, singleQuote, backtickTemplate},
+		rawString:     jsRegexLiteral,
+		directives:    tsDirectives,
+		docBlocks:     cDocBlocks,
+	}
+	rustSyntax = &syntax{
+		lineComments:  []string{"//"},
+		blockComments: cBlock,
+		nestedBlocks:  true,
+		quotes:        []quote{doubleQuoteMulti, charQuote},
+		rawString:     rustRawString,
+		docLines:      cDocLines,
+		docBlocks:     cDocBlocks,
+	}
+	zigSyntax = &syntax{
+		lineComments: []string{"//"},
+		quotes:       []quote{doubleQuote, charQuote},
+		docLines:     cDocLines,
+	}
+	phpSyntax = &syntax{
+		lineComments:  []string{"//"},
+		blockComments: cBlock,
+		quotes:        []quote{doubleQuoteMulti, singleQuoteMulti},
+		docBlocks:     cDocBlocks,
+	}
+	protobufSyntax = &syntax{
+		lineComments:  []string{"//"},
+		blockComments: cBlock,
+		quotes:        []quote{doubleQuote, singleQuote},
+	}
+	cssSyntax = &syntax{
+		blockComments: cBlock,
+		quotes:        []quote{doubleQuote, singleQuote},
+		docBlocks:     cDocBlocks,
+	}
+	scssSyntax = &syntax{
+		lineComments:  []string{"//"},
+		linePosition:  wordStart,
+		blockComments: cBlock,
+		quotes:        []quote{doubleQuote, singleQuote},
+		docLines:      cDocLines,
+		docBlocks:     cDocBlocks,
+	}
+	hclSyntax = &syntax{
+		lineComments:  []string{"#", "//"},
+		blockComments: cBlock,
+		quotes:        []quote{doubleQuote},
+	}
+
+	pythonSyntax = &syntax{
+		lineComments: []string{"#"},
+		quotes:       []quote{tripleDoubleQuote, tripleSingleQuote, doubleQuote, singleQuote},
+		directives:   pythonDirectives,
+	}
+	rubySyntax = &syntax{
+		lineComments: []string{"#"},
+		quotes:       []quote{doubleQuoteMulti, singleQuoteMulti},
+		directives:   []string{"#!", "# -*-", "# frozen_string_literal:"},
+	}
+	shellSyntax = &syntax{
+		lineComments: []string{"#"},
+		linePosition: wordStart,
+		quotes:       []quote{doubleQuoteMulti, shellSingleQuote},
+		directives:   hashDirectives,
+		heredocs:     true,
+	}
+	perlSyntax = &syntax{
+		lineComments: []string{"#"},
+		linePosition: wordStart,
+		quotes:       []quote{doubleQuoteMulti, singleQuoteMulti},
+		directives:   hashDirectives,
+	}
+	rSyntax = &syntax{
+		lineComments: []string{"#"},
+		quotes:       []quote{doubleQuoteMulti, singleQuoteMulti},
+		directives:   hashDirectives,
+		docLines:     []string{"#'"},
+	}
+	elixirSyntax = &syntax{
+		lineComments: []string{"#"},
+		quotes:       []quote{tripleDoubleQuote, tripleSingleQuote, doubleQuoteMulti, singleQuoteMulti},
+		directives:   hashDirectives,
+	}
+	juliaSyntax = &syntax{
+		lineComments:  []string{"#"},
+		blockComments: [][2]string{{"#=", "=#"}},
+		nestedBlocks:  true,
+		quotes:        []quote{tripleDoubleQuote, doubleQuoteMulti, charQuote},
+		directives:    hashDirectives,
+	}
+	powershellSyntax = &syntax{
+		lineComments:  []string{"#"},
+		blockComments: [][2]string{{"<#", "#>"}},
+		quotes:        []quote{{open: `"`, close: `"`, multiline: true}, powershellVerbatim},
+		directives:    []string{"#!", "#requires"},
+		docBlocks:     []string{"<#"},
+	}
+	hashSyntax = &syntax{
+		lineComments: []string{"#"},
+		linePosition: wordStart,
+		quotes:       []quote{doubleQuote},
+		directives:   hashDirectives,
+	}
+	dockerfileSyntax = &syntax{
+		lineComments: []string{"#"},
+		linePosition: lineStart,
+		directives:   dockerDirectives,
+	}
+	yamlSyntax = &syntax{
+		lineComments: []string{"#"},
+		linePosition: wordStart,
+		quotes:       []quote{doubleQuote, yamlSingleQuote},
+	}
+	tomlSyntax = &syntax{
+		lineComments: []string{"#"},
+		quotes: []quote{
+			tripleDoubleQuote,
+			{open: `'''`, close: `'''`, multiline: true},
+			doubleQuote,
+			{open: `'`, close: `'`},
+		},
+	}
+	iniSyntax = &syntax{
+		lineComments: []string{";", "#"},
+		linePosition: lineStart,
+	}
+	propertiesSyntax = &syntax{
+		lineComments: []string{"#", "!"},
+		linePosition: lineStart,
+	}
+
+	sqlSyntax = &syntax{
+		lineComments:  []string{"--"},
+		blockComments: cBlock,
+		quotes:        []quote{sqlSingleQuote, sqlDoubleQuote},
+		rawString:     dollarQuotedString,
+	}
+
+	htmlSyntax = &syntax{
+		blockComments: htmlBlock,
+	}
+
+	lispSyntax = &syntax{
+		lineComments:  []string{";"},
+		blockComments: lispBlock,
+		nestedBlocks:  true,
+		quotes:        []quote{doubleQuoteMulti},
+		escapeOutside: true,
+	}
+	clojureSyntax = &syntax{
+		lineComments:  []string{";"},
+		quotes:        []quote{doubleQuoteMulti},
+		escapeOutside: true,
+	}
+)
+
+// syntaxes maps the language identifiers produced by the processor to
+// their comment syntax.
+var syntaxes = map[string]*syntax{
+	"go": goSyntax,
+
+	"c":          cSyntax,
+	"cpp":        cSyntax,
+	"objectivec": cSyntax,
+	"d":          cSyntax,
+
+	"java":   javaSyntax,
+	"kotlin": nestedJavaSyntax,
+	"scala":  nestedJavaSyntax,
+	"swift":  nestedJavaSyntax,
+	"dart":   dartSyntax,
+	"groovy": groovySyntax,
+	"csharp": csharpSyntax,
+
+	"javascript":   jsSyntax,
+	"typescript":   jsSyntax,
+	"jsx":          jsSyntax,
+	"tsx":          jsSyntax,
+	"actionscript": jsSyntax,
+
+	"rust":      rustSyntax,
+	"zig":       zigSyntax,
+	"php":       phpSyntax,
+	"protobuf":  protobufSyntax,
+	"css":       cssSyntax,
+	"scss":      scssSyntax,
+	"less":      scssSyntax,
+	"sass":      scssSyntax,
+	"hcl":       hclSyntax,
+	"terraform": hclSyntax,
+
+	"python":     pythonSyntax,
+	"ruby":       rubySyntax,
+	"shell":      shellSyntax,
+	"bash":       shellSyntax,
+	"zsh":        shellSyntax,
+	"perl":       perlSyntax,
+	"r":          rSyntax,
+	"elixir":     elixirSyntax,
+	"julia":      juliaSyntax,
+	"powershell": powershellSyntax,
+	"tcl":        hashSyntax,
+	"makefile":   hashSyntax,
+	"dockerfile": dockerfileSyntax,
+	"yaml":       yamlSyntax,
+	"toml":       tomlSyntax,
+	"ini":        iniSyntax,
+	"properties": propertiesSyntax,
+
+	"sql": sqlSyntax,
+
+	"html":   htmlSyntax,
+	"xml":    htmlSyntax,
+	"vue":    htmlSyntax,
+	"svelte": htmlSyntax,
+
+	"lisp":    lispSyntax,
+	"el":      lispSyntax,
+	"scheme":  lispSyntax,
+	"clojure": clojureSyntax,
+}
+
+// SupportsComments reports whether StripComments knows the comment syntax of lang.
+func SupportsComments(lang string) bool {
+	_, ok := syntaxes[lang]
+	return ok
+}
+
+// StripComments removes line and block comments from src, which is written
+// in lang. String and raw string literals are left untouched, as are comments
+// that carry meaning to tooling, such as shebangs and Go build constraints.
+// If keepDocs is set, documentation comments are kept as well. Lines that
+// consisted of nothing but a comment are removed entirely, and lines a block
+// comment spanned are joined; the LineMap returned tells where the remaining
+// lines come from. Content in a language without known comment syntax is
+// returned unchanged.
+func StripComments(src []byte, lang string, keepDocs bool) ([]byte, LineMap) {
+	s, ok := syntaxes[lang]
+	if !ok {
+		return src, nil
+	}
+	l := &lexer{src: src, syn: s, keepDocs: keepDocs, lines: newLineMapper()}
+	l.out.Grow(len(src))
+	out := l.run()
+	return out, l.lines.lineMap(CountLines(out))
+}
+
+// literalLines reports for every line of src, which is written in lang,
+// whether it starts inside a string literal or heredoc, so that its leading
+// whitespace belongs to the literal. Comments are skipped, so that quotes
+// in them start no literal.
+func literalLines(src []byte, lang string) []bool {
+	inside := make([]bool, CountLines(src))
+	s, ok := syntaxes[lang]
+	if !ok {
+		return inside
+	}
+	l := &lexer{src: src, syn: s}
+	line := 0
+	var heredocs []heredoc
+	for i := 0; i < len(src); {
+		if src[i] == '\n' {
+			i++
+			line++
+			for _, h := range heredocs {
+				i, line = h.skipBody(src, i, line, inside)
+			}
+			heredocs = nil
+			continue
+		}
+
+		end, literal := i+1, false
+		if s.heredocs {
+			if h, e, ok := matchHeredoc(src, i); ok {
+				heredocs = append(heredocs, h)
+				i = e
+				continue
+			}
+		}
+		if c := src[i]; c == '\\' && s.escapeOutside && i+1 < len(src) && src[i+1] != '\n' {
+			end = i + 2
+		} else if e := rawStringEnd(s, src, i); e > i {
+			end, literal = e, true
+		} else if e, ok := l.matchQuote(i); ok {
+			end, literal = e, true
+		} else if e, ok := l.matchLineComment(i); ok {
+			end = e
+		} else if e, ok := l.matchBlockComment(i); ok {
+			end = e
+		}
+		if end == i+1 {
+			i++
+			continue
+		}
+		for _, c := range src[i:end] {
+			if c == '\n' {
+				line++
+				if literal && line < len(inside) {
+					inside[line] = true
+				}
+			}
+		}
+		i = end
+	}
+	return inside
+}
+
+// rawStringEnd returns the end of a raw string literal of s starting at i, or -1.
+func rawStringEnd(s *syntax, src []byte, i int) int {
+	if s.rawString == nil {
+		return -1
+	}
+	return s.rawString(src, i)
+}
+
+// heredoc is a pending shell here-document, whose body starts on the line
+// after its operator.
+type heredoc struct {
+	delimiter string
+	// stripTabs is set for <<-, whose body and delimiter line may be indented with tabs.
+	stripTabs bool
+}
+
+// matchHeredoc matches a here-document operator and its delimiter, such as
+// <<EOF or <<-'EOF', starting at i and returns the end of the delimiter.
+func matchHeredoc(src []byte, i int) (heredoc, int, bool) {
+	if !bytes.HasPrefix(src[i:], []byte("<<")) || bytes.HasPrefix(src[i:], []byte("<<<")) {
+		return heredoc{}, 0, false
+	}
+	var h heredoc
+	j := i + 2
+	if j < len(src) && src[j] == '-' {
+		h.stripTabs = true
+		j++
+	}
+	for j < len(src) && (src[j] == ' ' || src[j] == '\t') {
+		j++
+	}
+	var quote byte
+	if j < len(src) && (src[j] == '\'' || src[j] == '"') {
+		quote = src[j]
+		j++
+	} else if j < len(src) && src[j] == '\\' {
+		j++
+	}
+	start := j
+	for j < len(src) && isIdent(src[j]) {
+		j++
+	}
+	// A digit rules out shifts such as $((1<<2)).
+	if j == start || src[start] >= '0' && src[start] <= '9' {
+		return heredoc{}, 0, false
+	}
+	h.delimiter = string(src[start:j])
+	if quote != 0 {
+		if j >= len(src) || src[j] != quote {
+			return heredoc{}, 0, false
+		}
+		j++
+	}
+	return h, j, true
+}
+
+// skipBody skips the body of the here-document starting at offset i, on
+// line, up to and including its delimiter line, and marks its lines in
+// inside. It returns the offset and line after it.
+func (h heredoc) skipBody(src []byte, i, line int, inside []bool) (int, int) {
+	for i < len(src) {
+		end := bytes.IndexByte(src[i:], '\n')
+		if end < 0 {
+			end = len(src)
+		} else {
+			end += i + 1
+		}
+		if line < len(inside) {
+			inside[line] = true
+		}
+		body, _ := splitEOL(src[i:end])
+		if h.stripTabs {
+			body = bytes.TrimLeft(body, "\t")
+		}
+		i = end
+		line++
+		if string(body) == h.delimiter {
+			break
+		}
+	}
+	return i, line
+}
+
+type lexer struct {
+	src      []byte
+	syn      *syntax
+	keepDocs bool
+
+	out bytes.Buffer
+	// lineOut is the offset in out where the current output line starts.
+	lineOut int
+	// removed reports whether a comment was removed from the current line.
+	removed bool
+	lines   *lineMapper
+}
+
+func (l *lexer) run() []byte {
+	src := l.src
+	for i := 0; i < len(src); {
+		c := src[i]
+		switch {
+		case c == '\n':
+			if l.endLine() {
+				l.lines.newline(false)
+				l.lines.drop()
+			} else {
+				l.out.WriteByte('\n')
+				l.lines.newline(true)
+			}
+			l.lineOut = l.out.Len()
+			l.removed = false
+			i++
+			continue
+		case c == '\\' && l.syn.escapeOutside && i+1 < len(src):
+			l.out.Write(src[i : i+2])
+			if src[i+1] == '\n' {
+				l.lines.newline(true)
+			}
+			i += 2
+			continue
+		}
+
+		if l.syn.rawString != nil {
+			if end := l.syn.rawString(src, i); end > i {
+				l.emit(src[i:end])
+				i = end
+				continue
+			}
+		}
+		if end, ok := l.matchQuote(i); ok {
+			l.emit(src[i:end])
+			i = end
+			continue
+		}
+		if end, ok := l.matchLineComment(i); ok {
+			text := src[i:end]
+			if l.keepLineComment(text, i) {
+				l.out.Write(text)
+			} else {
+				l.removed = true
+			}
+			i = end
+			continue
+		}
+		if end, ok := l.matchBlockComment(i); ok {
+			text := src[i:end]
+			if l.keepBlockComment(text) {
+				l.emit(text)
+			} else {
+				l.removed = true
+				for range bytes.Count(text, []byte("\n")) {
+					l.lines.newline(false)
+				}
+				if l.needsSeparator(end) {
+					l.out.WriteByte(' ')
+				} else if l.onlyIndentation() {
+					for end < len(src) && (src[end] == ' ' || src[end] == '\t') {
+						end++
+					}
+				}
+			}
+			i = end
+			continue
+		}
+
+		l.out.WriteByte(c)
+		i++
+	}
+	l.endLine()
+	return l.out.Bytes()
+}
+
+// emit writes text that may span several lines to the output.
+func (l *lexer) emit(text []byte) {
+	l.out.Write(text)
+	for range bytes.Count(text, []byte("\n")) {
+		l.lines.newline(true)
+	}
+	if nl := bytes.LastIndexByte(text, '\n'); nl >= 0 {
+		l.lineOut = l.out.Len() - len(text) + nl + 1
+		l.removed = false
+	}
+}
+
+// endLine tidies up the current output line once its source line ends.
+// It reports whether the line held nothing but removed comments, in which
+// case the line is dropped and its newline must not be written.
+func (l *lexer) endLine() bool {
+	if !l.removed {
+		return false
+	}
+	line := l.out.Bytes()[l.lineOut:]
+	trimmed := bytes.TrimRight(line, " \t\r")
+	l.out.Truncate(l.lineOut + len(trimmed))
+	return len(trimmed) == 0
+}
+
+// onlyIndentation reports whether the current output line holds nothing but blanks.
+func (l *lexer) onlyIndentation() bool {
+	return len(bytes.Trim(l.out.Bytes()[l.lineOut:], " \t")) == 0
+}
+
+// needsSeparator reports whether removing a block comment ending at end
+// would glue together two tokens.
+func (l *lexer) needsSeparator(end int) bool {
+	out := l.out.Bytes()
+	if len(out) == l.lineOut || isSpace(out[len(out)-1]) {
+		return false
+	}
+	return end < len(l.src) && !isSpace(l.src[end])
+}
+
+func (l *lexer) matchQuote(i int) (int, bool) {
+	src := l.src
+	for _, q := range l.syn.quotes {
+		if !bytes.HasPrefix(src[i:], []byte(q.open)) {
+			continue
+		}
+		if q.char {
+			end, ok := charLiteral(src, i)
+			if !ok {
+				return 0, false
+			}
+			return end, true
+		}
+		return scanQuote(src, i, q), true
+	}
+	return 0, false
+}
+
+func scanQuote(src []byte, i int, q quote) int {
+	j := i + len(q.open)
+	for j < len(src) {
+		switch {
+		case q.escape && src[j] == '\\':
+			j += 2
+			continue
+		case bytes.HasPrefix(src[j:], []byte(q.close)):
+			return j + len(q.close)
+		case src[j] == '\n' && !q.multiline:
+			return j
+		}
+		j++
+	}
+	return len(src)
+}
+
+// charLiteral matches a character literal such as 'a' or '\n' starting at i.
+// It fails for a lone quote, so that Rust lifetimes and C++ digit separators
+// are treated as ordinary code.
+func charLiteral(src []byte, i int) (int, bool) {
+	j := i + 1
+	if j >= len(src) {
+		return 0, false
+	}
+	if src[j] == '\\' {
+		for k := j + 1; k < len(src) && k < j+12; k++ {
+			if src[k] == '\n' {
+				return 0, false
+			}
+			if src[k] == '\'' && k > j+1 {
+				return k + 1, true
+			}
+		}
+		return 0, false
+	}
+	_, size := utf8.DecodeRune(src[j:])
+	if src[j] == '\'' || src[j] == '\n' || j+size >= len(src) || src[j+size] != '\'' {
+		return 0, false
+	}
+	return j + size + 1, true
+}
+
+func (l *lexer) matchLineComment(i int) (int, bool) {
+	src := l.src
+	for _, marker := range l.syn.lineComments {
+		if !bytes.HasPrefix(src[i:], []byte(marker)) {
+			continue
+		}
+		switch l.syn.linePosition {
+		case wordStart:
+			if i > 0 && !isSpace(src[i-1]) {
+				return 0, false
+			}
+		case lineStart:
+			if !l.atLineStart(i) {
+				return 0, false
+			}
+		}
+		end := bytes.IndexByte(src[i:], '\n')
+		if end < 0 {
+			return len(src), true
+		}
+		return i + end, true
+	}
+	return 0, false
+}
+
+func (l *lexer) matchBlockComment(i int) (int, bool) {
+	src := l.src
+	for _, block := range l.syn.blockComments {
+		open, close := []byte(block[0]), []byte(block[1])
+		if !bytes.HasPrefix(src[i:], open) {
+			continue
+		}
+		depth := 1
+		j := i + len(open)
+		for j < len(src) {
+			switch {
+			case bytes.HasPrefix(src[j:], close):
+				depth--
+				j += len(close)
+				if depth == 0 || !l.syn.nestedBlocks {
+					return j, true
+				}
+			case l.syn.nestedBlocks && bytes.HasPrefix(src[j:], open):
+				depth++
+				j += len(open)
+			default:
+				j++
+			}
+		}
+		return len(src), true
+	}
+	return 0, false
+}
+
+func (l *lexer) keepLineComment(text []byte, i int) bool {
+	for _, d := range l.syn.directives {
+		if bytes.HasPrefix(text, []byte(d)) {
+			return true
+		}
+	}
+	if !l.keepDocs {
+		return false
+	}
+	for _, d := range l.syn.docLines {
+		if bytes.HasPrefix(text, []byte(d)) {
+			return true
+		}
+	}
+	return len(l.syn.docFollowers) > 0 && l.atLineStart(i) && l.precedesDeclaration(i)
+}
+
+func (l *lexer) keepBlockComment(text []byte) bool {
+	if !l.keepDocs {
+		return false
+	}
+	for _, d := range l.syn.docBlocks {
+		// An empty comment such as /**/ is not documentation.
+		if bytes.HasPrefix(text, []byte(d)) && len(text) > len(d)+1 {
+			return true
+		}
+	}
+	return false
+}
+
+// precedesDeclaration reports whether the group of whole-line comments
+// containing the comment at i is directly followed by a declaration.
+func (l *lexer) precedesDeclaration(i int) bool {
+	rest := l.src[i:]
+	for {
+		nl := bytes.IndexByte(rest, '\n')
+		if nl < 0 {
+			return false
+		}
+		rest = rest[nl+1:]
+		line := bytes.TrimLeft(rest, " \t")
+		if l.startsWithLineComment(line) {
+			continue
+		}
+		for _, f := range l.syn.docFollowers {
+			if bytes.HasPrefix(line, []byte(f)) {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+func (l *lexer) startsWithLineComment(line []byte) bool {
+	for _, marker := range l.syn.lineComments {
+		if bytes.HasPrefix(line, []byte(marker)) {
+			return true
+		}
+	}
+	return false
+}
+
+// atLineStart reports whether only blanks precede offset i on its line.
+func (l *lexer) atLineStart(i int) bool {
+	for j := i - 1; j >= 0 && l.src[j] != '\n'; j-- {
+		if l.src[j] != ' ' && l.src[j] != '\t' {
+			return false
+		}
+	}
+	return true
+}
+
+// rustRawString matches raw string literals such as r"..." and br#"..."#.
+func rustRawString(src []byte, i int) int {
+	if i > 0 && isIdent(src[i-1]) {
+		return -1
+	}
+	j := i
+	if j < len(src) && src[j] == 'b' {
+		j++
+	}
+	if j >= len(src) || src[j] != 'r' {
+		return -1
+	}
+	j++
+	hashes := 0
+	for j < len(src) && src[j] == '#' {
+		hashes++
+		j++
+	}
+	if j >= len(src) || src[j] != '"' {
+		return -1
+	}
+	closing := append([]byte{'"'}, bytes.Repeat([]byte{'#'}, hashes)...)
+	end := bytes.Index(src[j+1:], closing)
+	if end < 0 {
+		return len(src)
+	}
+	return j + 1 + end + len(closing)
+}
+
+// cppRawString matches C++ raw string literals such as R"delim(...)delim".
+func cppRawString(src []byte, i int) int {
+	if src[i] != 'R' || i+1 >= len(src) || src[i+1] != '"' {
+		return -1
+	}
+	if i > 0 && isIdent(src[i-1]) {
+		start := i - 1
+		for start > 0 && isIdent(src[start-1]) {
+			start--
+		}
+		switch string(src[start:i]) {
+		case "u8", "u", "U", "L":
+		default:
+			return -1
+		}
+	}
+	open := bytes.IndexByte(src[i+2:], '(')
+	if open < 0 || open > 16 {
+		return -1
+	}
+	delim := src[i+2 : i+2+open]
+	closing := append(append([]byte{')'}, delim...), '"')
+	end := bytes.Index(src[i+2+open+1:], closing)
+	if end < 0 {
+		return len(src)
+	}
+	return i + 2 + open + 1 + end + len(closing)
+}
+
+// regexKeywords are the JavaScript keywords a regular expression literal may follow.
+var regexKeywords = map[string]bool{
+	"await": true, "case": true, "delete": true, "do": true, "else": true,
+	"in": true, "instanceof": true, "new": true, "of": true, "return": true,
+	"throw": true, "typeof": true, "void": true, "yield": true,
+}
+
+// jsRegexLiteral matches JavaScript regular expression literals such as
+// /https?:\/\//g, which may hold what looks like a comment. A slash starts
+// one where a value is expected, such as after an operator, an opening
+// bracket or a keyword, and not where it divides, as after a name.
+func jsRegexLiteral(src []byte, i int) int {
+	if src[i] != '/' || i+1 >= len(src) || src[i+1] == '/' || src[i+1] == '*' {
+		return -1
+	}
+	j := i - 1
+	for j >= 0 && isSpace(src[j]) {
+		j--
+	}
+	if j >= 0 && isIdent(src[j]) {
+		start := j
+		for start > 0 && isIdent(src[start-1]) {
+			start--
+		}
+		if !regexKeywords[string(src[start:j+1])] || start > 0 && src[start-1] == '.' {
+			return -1
+		}
+	} else if j >= 0 && (src[j] == ')' || src[j] == ']' || src[j] == '"' || src[j] == '\'' || src[j] == '`') {
+		return -1
+	}
+
+	class := false
+	for j = i + 1; j < len(src); j++ {
+		switch src[j] {
+		case '\\':
+			j++
+		case '[':
+			class = true
+		case ']':
+			class = false
+		case '\n':
+			return -1
+		case '/':
+			if !class {
+				for j++; j < len(src) && isIdent(src[j]); j++ {
+				}
+				return j
+			}
+		}
+	}
+	return -1
+}
+
+// dollarQuotedString matches PostgreSQL dollar-quoted strings such as $$...$$ or $fn$...$fn$.
+func dollarQuotedString(src []byte, i int) int {
+	if src[i] != '$' || (i > 0 && isIdent(src[i-1])) {
+		return -1
+	}
+	j := i + 1
+	for j < len(src) && isIdent(src[j]) && !(src[j] >= '0' && src[j] <= '9' && j == i+1) {
+		j++
+	}
+	if j >= len(src) || src[j] != '$' {
+		return -1
+	}
+	tag := src[i : j+1]
+	end := bytes.Index(src[j+1:], tag)
+	if end < 0 {
+		return len(src)
+	}
+	return j + 1 + end + len(tag)
+}
+
+func isIdent(c byte) bool {
+	return c == '_' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9'
+}
+
+func isSpace(c byte) bool {
+	return c == ' ' || c == '\t' || c == '\r' || c == '\n'
+}
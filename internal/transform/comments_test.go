@@ -0,0 +1,129 @@
+package transform
+
+import (
+	"testing"
+)
+
+func TestStripComments(t *testing.T) {
+	testCases := []struct {
+		name     string
+		lang     string
+		keepDocs bool
+		input    string
+		expected string
+	}{
+		{
+			name:     "Go line and block comments",
+			lang:     "go",
+			input:    "package main\n\n// helper does things.\nfunc helper() {\n\tx := 1 // trailing\n\t/* block */ y := 2\n}\n",
+			expected: "package main\n\nfunc helper() {\n\tx := 1\n\ty := 2\n}\n",
+		},
+		{
+			name:     "Go keeps doc comments and directives",
+			lang:     "go",
+			keepDocs: true,
+			input:    "//go:build linux\n\n// Package main is a demo.\npackage main\n\nfunc f() {\n\t// inside\n\treturn\n}\n",
+			expected: "//go:build linux\n\n// Package main is a demo.\npackage main\n\nfunc f() {\n\treturn\n}\n",
+		},
+		{
+			name:     "Go strings and raw strings are untouched",
+			lang:     "go",
+			input:    "s := \"// not a comment\"\nr := `/* raw\n// still raw */`\nc := '/'\n",
+			expected: "s := \"// not a comment\"\nr := `/* raw\n// still raw */`\nc := '/'\n",
+		},
+		{
+			name:     "JavaScript regular expression literals",
+			lang:     "javascript",
+			input:    "const re = /https?:\\/\\//; // url\nconst half = a / b / 2; // ratio\nif (/\\/\\*/.test(s)) return /[/]*/g;\n",
+			expected: "const re = /https?:\\/\\//;\nconst half = a / b / 2;\nif (/\\/\\*/.test(s)) return /[/]*/g;\n",
+		},
+		{
+			name:     "Block comment between tokens",
+			lang:     "c",
+			input:    "int/**/x = a/* sum */+b;\n",
+			expected: "int x = a +b;\n",
+		},
+		{
+			name:     "C++ raw string",
+			lang:     "cpp",
+			input:    "auto s = R\"x(// keep /* me */)x\"; // drop\n",
+			expected: "auto s = R\"x(// keep /* me */)x\";\n",
+		},
+		{
+			name:     "Rust lifetimes, raw strings and doc comments",
+			lang:     "rust",
+			keepDocs: true,
+			input:    "/// Docs.\nfn f<'a>(s: &'a str) -> &'a str { // note\n    let r = r#\"// \"quoted\"\"#;\n    s\n}\n",
+			expected: "/// Docs.\nfn f<'a>(s: &'a str) -> &'a str {\n    let r = r#\"// \"quoted\"\"#;\n    s\n}\n",
+		},
+		{
+			name:     "Nested block comments",
+			lang:     "rust",
+			input:    "a /* outer /* inner */ still */ b\n",
+			expected: "a  b\n",
+		},
+		{
+			name:     "Javadoc dropped without keepDocs",
+			lang:     "java",
+			input:    "/**\n * Docs.\n */\nclass A {}\n",
+			expected: "class A {}\n",
+		},
+		{
+			name:     "Python keeps shebang and docstrings",
+			lang:     "python",
+			input:    "#!/usr/bin/env python3\n# comment\ndef f():\n    \"\"\"Doc # string.\"\"\"\n    return '#'  # trailing\n",
+			expected: "#!/usr/bin/env python3\ndef f():\n    \"\"\"Doc # string.\"\"\"\n    return '#'\n",
+		},
+		{
+			name:     "Shell hash inside words",
+			lang:     "shell",
+			input:    "echo ${#args} $# # count\n# full line\n",
+			expected: "echo ${#args} $#\n",
+		},
+		{
+			name:     "YAML",
+			lang:     "yaml",
+			input:    "# header\nkey: \"value # not comment\" # comment\nurl: http://x#frag\n",
+			expected: "key: \"value # not comment\"\nurl: http://x#frag\n",
+		},
+		{
+			name:     "SQL",
+			lang:     "sql",
+			input:    "SELECT '--text' -- comment\nFROM t /* block */;\n",
+			expected: "SELECT '--text'\nFROM t ;\n",
+		},
+		{
+			name:     "SQL dollar quoting",
+			lang:     "sql",
+			input:    "CREATE FUNCTION f() AS $$ -- body\n$$; -- done\n",
+			expected: "CREATE FUNCTION f() AS $$ -- body\n$$;\n",
+		},
+		{
+			name:     "HTML",
+			lang:     "html",
+			input:    "<div>\n  <!-- hidden -->\n  <p>text</p>\n</div>\n",
+			expected: "<div>\n  <p>text</p>\n</div>\n",
+		},
+		{
+			name:     "Lisp",
+			lang:     "lisp",
+			input:    "(defun f () ; comment\n  #| block #| nested |# |#\n  \"; string\" #\\;)\n",
+			expected: "(defun f ()\n  \"; string\" #\\;)\n",
+		},
+		{
+			name:     "Unknown language is unchanged",
+			lang:     "brainfuck",
+			input:    "// not stripped\n",
+			expected: "// not stripped\n",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			got, _ := StripComments([]byte(tc.input), tc.lang, tc.keepDocs)
+			if string(got) != tc.expected {
+				t.Errorf("StripComments() mismatch:\n--- EXPECTED ---\n%q\n\n--- GOT ---\n%q", tc.expected, got)
+			}
+		})
+	}
+}
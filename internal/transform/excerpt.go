@@ -0,0 +1,29 @@
+package transform
+
+import "regexp"
+
+// MatchingLines returns the numbers of the lines of src that re matches,
+// counting from 1.
+func MatchingLines(src []byte, re *regexp.Regexp) []int {
+	var lines []int
+	for i, line := range splitLines(src) {
+		if re.Match(line) {
+			lines = append(lines, i+1)
+		}
+	}
+	return lines
+}
+
+// Excerpt returns the regions of src around the given line numbers, in
+// increasing order, with context lines before and after each of them.
+// Overlapping or adjacent regions are merged.
+func Excerpt(src []byte, lines []int, context int) []Region {
+	all := splitLines(src)
+	keep := make([]bool, len(all))
+	for _, n := range lines {
+		for i := max(n-1-context, 0); i < min(n+context, len(all)); i++ {
+			keep[i] = true
+		}
+	}
+	return keptRegions(all, keep)
+}
@@ -0,0 +1,58 @@
+package transform
+
+import (
+	"reflect"
+	"regexp"
+	"testing"
+)
+
+func TestMatchingLines(t *testing.T) {
+	src := []byte("foo\nbar\nfoobar\nbaz")
+	expected := []int{1, 3}
+	if got := MatchingLines(src, regexp.MustCompile(`^foo`)); !reflect.DeepEqual(got, expected) {
+		t.Errorf("MatchingLines() = %v; want %v", got, expected)
+	}
+}
+
+func TestExcerpt(t *testing.T) {
+	testCases := []struct {
+		name     string
+		lines    []int
+		context  int
+		expected string
+	}{
+		{
+			name:     "No context",
+			lines:    []int{3, 7},
+			expected: "... [2 lines omitted] ...\nline 3\n... [3 lines omitted] ...\nline 7\n... [3 lines omitted] ...\n",
+		},
+		{
+			name:     "Context",
+			lines:    []int{3},
+			context:  1,
+			expected: "... [1 line omitted] ...\nline 2\nline 3\nline 4\n... [6 lines omitted] ...\n",
+		},
+		{
+			name:     "Merged regions at the edges",
+			lines:    []int{1, 4, 10},
+			context:  2,
+			expected: "line 1\nline 2\nline 3\nline 4\nline 5\nline 6\n... [1 line omitted] ...\nline 8\nline 9\nline 10\n",
+		},
+		{
+			name:     "Lines out of range",
+			lines:    []int{12},
+			context:  2,
+			expected: "... [9 lines omitted] ...\nline 10\n",
+		},
+	}
+
+	src := []byte(numberedLines(10))
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			got := string(JoinRegions(Excerpt(src, tc.lines, tc.context), 10, JoinOptions{}))
+			if got != tc.expected {
+				t.Errorf("Excerpt() =\n%s\nwant\n%s", got, tc.expected)
+			}
+		})
+	}
+}
@@ -0,0 +1,88 @@
+package transform
+
+// LineMap gives the number of the source line each line of a transformed
+// text comes from, counting from 1, for transforms that remove or join
+// lines. Where a line was joined with the lines after it, it has the number
+// of the first of them. A nil LineMap means every line was kept in place.
+type LineMap []int
+
+// Line returns the number of the source line line n of the text comes from.
+func (m LineMap) Line(n int) int {
+	if m == nil || n < 1 || n > len(m) {
+		return n
+	}
+	return m[n-1]
+}
+
+// Then returns the map of a text transformed with m and then with next.
+func (m LineMap) Then(next LineMap) LineMap {
+	if m == nil || next == nil {
+		if m == nil {
+			return next
+		}
+		return m
+	}
+	out := make(LineMap, len(next))
+	for i, n := range next {
+		out[i] = m.Line(n)
+	}
+	return out
+}
+
+// split splits r into runs of lines whose source lines follow each other,
+// numbered by their first source line.
+func (m LineMap) split(r Region) []Region {
+	if m == nil {
+		return []Region{r}
+	}
+	var runs []Region
+	n := r.FirstLine
+	for _, line := range splitLines(r.Content) {
+		if last := len(runs) - 1; last >= 0 && m.Line(n) == m.Line(n-1)+1 {
+			runs[last].Content = append(runs[last].Content, line...)
+		} else {
+			runs = append(runs, Region{FirstLine: m.Line(n), Content: append([]byte(nil), line...)})
+		}
+		n++
+	}
+	return runs
+}
+
+// lineMapper builds the LineMap of a transform that copies its source in
+// order, one line at a time.
+type lineMapper struct {
+	m LineMap
+	// source is the number of the source line being read.
+	source  int
+	changed bool
+}
+
+func newLineMapper() *lineMapper {
+	return &lineMapper{m: LineMap{1}, source: 1}
+}
+
+// newline records that the source line ended, with its newline written to
+// the output if written is set.
+func (lm *lineMapper) newline(written bool) {
+	lm.source++
+	if written {
+		lm.m = append(lm.m, lm.source)
+	} else {
+		lm.changed = true
+	}
+}
+
+// drop records that the output line being written was dropped, so that it
+// starts over with the next source line.
+func (lm *lineMapper) drop() {
+	lm.m[len(lm.m)-1] = lm.source
+	lm.changed = true
+}
+
+// lineMap returns the map of the output, which has the given number of lines.
+func (lm *lineMapper) lineMap(lines int) LineMap {
+	if !lm.changed {
+		return nil
+	}
+	return lm.m[:min(lines, len(lm.m))]
+}
@@ -0,0 +1,103 @@
+package transform
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestLineMaps(t *testing.T) {
+	testCases := []struct {
+		name      string
+		transform func([]byte) ([]byte, LineMap)
+		input     string
+		expected  string
+		lines     LineMap
+	}{
+		{
+			name: "Strip comments",
+			transform: func(src []byte) ([]byte, LineMap) {
+				return StripComments(src, "go", false)
+			},
+			input:    "package a\n\n// Doc.\nfunc f() { /* a\nb */ }\n/*\nend */\nvar x = 1 // x\n",
+			expected: "package a\n\nfunc f() {  }\nvar x = 1\n",
+			lines:    LineMap{1, 2, 4, 8},
+		},
+		{
+			name: "Strip comments keeps lines",
+			transform: func(src []byte) ([]byte, LineMap) {
+				return StripComments(src, "go", false)
+			},
+			input:    "a := `x\ny` // z\nb\n",
+			expected: "a := `x\ny`\nb\n",
+		},
+		{
+			name:      "Collapse blank lines",
+			transform: CollapseBlankLines,
+			input:     "a\n\n\n  \nb\n\n\nc",
+			expected:  "a\n\nb\n\nc",
+			lines:     LineMap{1, 2, 5, 6, 8},
+		},
+		{
+			name: "Mask multi-line values",
+			transform: func(src []byte) ([]byte, LineMap) {
+				return MaskValues(src, FormatYAML)
+			},
+			input:    "key: |\n  abc\n  def\nport: 80\n",
+			expected: "key: <string:7 chars>\nport: <int>\n",
+			lines:    LineMap{1, 4},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			got, lines := tc.transform([]byte(tc.input))
+			if string(got) != tc.expected || !reflect.DeepEqual(lines, tc.lines) {
+				t.Errorf("got %q, %v; want %q, %v", got, lines, tc.expected, tc.lines)
+			}
+		})
+	}
+}
+
+func TestLineMapThen(t *testing.T) {
+	first := LineMap{1, 3, 4, 6}
+	if got, expected := first.Then(LineMap{1, 3, 4}), (LineMap{1, 4, 6}); !reflect.DeepEqual(got, expected) {
+		t.Errorf("Then() = %v; want %v", got, expected)
+	}
+	if got := first.Then(nil); !reflect.DeepEqual(got, first) {
+		t.Errorf("Then(nil) = %v; want %v", got, first)
+	}
+}
+
+func TestJoinRegionsWithLineMap(t *testing.T) {
+	regions := []Region{{FirstLine: 1, Content: []byte("a\nb\n")}, {FirstLine: 4, Content: []byte("d\n")}}
+	lines := LineMap{1, 5, 6, 9}
+	testCases := []struct {
+		name     string
+		opts     JoinOptions
+		expected string
+	}{
+		{
+			name:     "Numbered by source line",
+			opts:     JoinOptions{LineNumbers: true, LineMap: lines},
+			expected: "1 | a\n5 | b\n... [1 line omitted] ...\n9 | d\n",
+		},
+		{
+			name:     "Relative numbers ignore the map",
+			opts:     JoinOptions{LineNumbers: true, Relative: true, LineMap: lines},
+			expected: "1 | a\n2 | b\n... [1 line omitted] ...\n3 | d\n",
+		},
+		{
+			name:     "Marked by source line",
+			opts:     JoinOptions{Marked: map[int]bool{5: true}, LineMap: lines},
+			expected: "  a\n> b\n... [1 line omitted] ...\n  d\n",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := string(JoinRegions(regions, 4, tc.opts)); got != tc.expected {
+				t.Errorf("JoinRegions() = %q; want %q", got, tc.expected)
+			}
+		})
+	}
+}
@@ -0,0 +1,109 @@
+package transform
+
+import (
+	"bytes"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// LineNumberPlaceholder marks where the line number goes in a line number format.
+const LineNumberPlaceholder = "{n}"
+
+// DefaultLineNumberFormat is the prefix written before each line when no format is given.
+const DefaultLineNumberFormat = "{n} | "
+
+// NumberLines prefixes every line of src with its line number, counting from
+// first. Numbers are right-aligned to width digits, or to the width of the
+// largest number if that is wider, so that all prefixes have the same length.
+// The number is substituted for LineNumberPlaceholder in format. Prefixes of
+// empty lines have their trailing blanks removed.
+func NumberLines(src []byte, first, width int, format string) []byte {
+	if len(src) == 0 {
+		return src
+	}
+	if format == "" {
+		format = DefaultLineNumberFormat
+	}
+	last := first + bytes.Count(src, []byte("\n"))
+	if !bytes.HasSuffix(src, []byte("\n")) {
+		last++
+	}
+	width = max(width, len(strconv.Itoa(last-1)))
+
+	out := make([]byte, 0, len(src)+(last-first)*(width+len(format)))
+	n := first
+	for line := range bytes.Lines(src) {
+		num := strconv.Itoa(n)
+		prefix := strings.Replace(format, LineNumberPlaceholder, strings.Repeat(" ", width-len(num))+num, 1)
+		body, _ := splitEOL(line)
+		if len(body) == 0 {
+			prefix = strings.TrimRight(prefix, " \t")
+		}
+		out = append(out, prefix...)
+		out = append(out, line...)
+		n++
+	}
+	return out
+}
+
+// MarkPrefix is written before the lines MarkLines marks.
+const MarkPrefix = "> "
+
+// MarkLines prefixes the lines of src whose numbers, counting from first, are
+// in marked with MarkPrefix, and the others with as many blanks so that they
+// stay aligned. Empty lines that are not marked are left empty.
+func MarkLines(src []byte, first int, marked map[int]bool) []byte {
+	blank := strings.Repeat(" ", len(MarkPrefix))
+	out := make([]byte, 0, len(src)+CountLines(src)*len(MarkPrefix))
+	n := first
+	for line := range bytes.Lines(src) {
+		if body, _ := splitEOL(line); marked[n] {
+			out = append(out, MarkPrefix...)
+		} else if len(body) > 0 {
+			out = append(out, blank...)
+		}
+		out = append(out, line...)
+		n++
+	}
+	return out
+}
+
+// StripLineNumbers removes prefixes written by NumberLines with format,
+// along with the marks MarkLines writes before them. Lines that do not carry
+// a prefix, such as elision markers, are kept as they are, but at least one
+// line must be numbered and the numbers must increase from line to line. It
+// reports whether src was numbered; if it was not, src is returned unchanged.
+func StripLineNumbers(src []byte, format string) ([]byte, bool) {
+	if format == "" {
+		format = DefaultLineNumberFormat
+	}
+	before, after, ok := strings.Cut(format, LineNumberPlaceholder)
+	if !ok {
+		return src, false
+	}
+	mark := `(?:` + regexp.QuoteMeta(MarkPrefix) + `|` + strings.Repeat(" ", len(MarkPrefix)) + `)?`
+	pattern := regexp.MustCompile(`^` + mark + regexp.QuoteMeta(before) + ` *(\d+)(` + regexp.QuoteMeta(after) + `|` + regexp.QuoteMeta(strings.TrimRight(after, " \t")) + `$)`)
+
+	out := make([]byte, 0, len(src))
+	prev, numbered := 0, false
+	for line := range bytes.Lines(src) {
+		body, eol := splitEOL(line)
+		m := pattern.FindSubmatchIndex(body)
+		if m == nil {
+			out = append(out, line...)
+			continue
+		}
+		n, err := strconv.Atoi(string(body[m[2]:m[3]]))
+		if err != nil || (numbered && n <= prev) {
+			return src, false
+		}
+		prev, numbered = n, true
+		out = append(out, body[m[1]:]...)
+		out = append(out, eol...)
+	}
+	if !numbered {
+		return src, false
+	}
+	return out, true
+}
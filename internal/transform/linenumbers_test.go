@@ -0,0 +1,117 @@
+package transform
+
+import (
+	"testing"
+)
+
+func TestNumberLines(t *testing.T) {
+	testCases := []struct {
+		name     string
+		input    string
+		first    int
+		width    int
+		format   string
+		expected string
+	}{
+		{
+			name:     "Default format",
+			input:    "a\n\nb\n",
+			first:    1,
+			expected: "1 | a\n2 |\n3 | b\n",
+		},
+		{
+			name:     "Aligned to the largest number",
+			input:    "a\nb\nc\n",
+			first:    9,
+			expected: " 9 | a\n10 | b\n11 | c\n",
+		},
+		{
+			name:     "Minimum width",
+			input:    "a",
+			first:    1,
+			width:    3,
+			expected: "  1 | a",
+		},
+		{
+			name:     "Custom format",
+			input:    "a\nb\n",
+			first:    42,
+			format:   "L{n}: ",
+			expected: "L42: a\nL43: b\n",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			got := string(NumberLines([]byte(tc.input), tc.first, tc.width, tc.format))
+			if got != tc.expected {
+				t.Errorf("NumberLines() mismatch:\n--- EXPECTED ---\n%q\n\n--- GOT ---\n%q", tc.expected, got)
+			}
+
+			stripped, ok := StripLineNumbers([]byte(got), tc.format)
+			if !ok || string(stripped) != tc.input {
+				t.Errorf("StripLineNumbers() = %q, %v; want %q, true", stripped, ok, tc.input)
+			}
+		})
+	}
+}
+
+func TestStripLineNumbers(t *testing.T) {
+	testCases := []struct {
+		name     string
+		input    string
+		expected string
+		ok       bool
+	}{
+		{
+			name:     "Elision markers are kept",
+			input:    "1 | a\n2 | b\n... [10 lines omitted] ...\n13 | c\n",
+			expected: "a\nb\n... [10 lines omitted] ...\nc\n",
+			ok:       true,
+		},
+		{
+			name:     "Marks are removed",
+			input:    "  1 | a\n  2 |\n> 3 | c\n... [1 line omitted] ...\n  5 | e\n",
+			expected: "a\n\nc\n... [1 line omitted] ...\ne\n",
+			ok:       true,
+		},
+		{
+			name:     "Unnumbered content",
+			input:    "a\nb\n",
+			expected: "a\nb\n",
+			ok:       false,
+		},
+		{
+			name:     "Decreasing numbers",
+			input:    "2 | a\n1 | b\n",
+			expected: "2 | a\n1 | b\n",
+			ok:       false,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			got, ok := StripLineNumbers([]byte(tc.input), "")
+			if string(got) != tc.expected || ok != tc.ok {
+				t.Errorf("StripLineNumbers() = %q, %v; want %q, %v", got, ok, tc.expected, tc.ok)
+			}
+		})
+	}
+}
+
+func TestMarkLines(t *testing.T) {
+	src := []byte("a\n\nc\nd")
+	expected := "  a\n\n> c\n  d"
+	if got := string(MarkLines(src, 5, map[int]bool{7: true})); got != expected {
+		t.Errorf("MarkLines() = %q; want %q", got, expected)
+	}
+
+	numbered := JoinRegions([]Region{{FirstLine: 1, Content: src}}, 4, JoinOptions{LineNumbers: true, Marked: map[int]bool{3: true}})
+	expected = "  1 | a\n  2 |\n> 3 | c\n  4 | d"
+	if string(numbered) != expected {
+		t.Errorf("JoinRegions() with marks = %q; want %q", numbered, expected)
+	}
+	if stripped, ok := StripLineNumbers(numbered, ""); string(stripped) != string(src) || !ok {
+		t.Errorf("StripLineNumbers() of marked lines = %q, %v; want %q, true", stripped, ok, src)
+	}
+}
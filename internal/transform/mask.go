@@ -0,0 +1,490 @@
+package transform
+
+import (
+	"path"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// ConfigFormat is the syntax of a configuration file whose values can be masked.
+type ConfigFormat string
+
+const (
+	FormatEnv        ConfigFormat = "env"
+	FormatProperties ConfigFormat = "properties"
+	FormatINI        ConfigFormat = "ini"
+	FormatYAML       ConfigFormat = "yaml"
+	FormatTOML       ConfigFormat = "toml"
+	FormatJSON       ConfigFormat = "json"
+)
+
+// DetectConfigFormat determines the configuration syntax of the file at the
+// slash-separated path p, written in lang. ok is false for other files.
+func DetectConfigFormat(p, lang string) (format ConfigFormat, ok bool) {
+	name := strings.ToLower(path.Base(p))
+	if name == ".env" || strings.HasPrefix(name, ".env.") || strings.HasSuffix(name, ".env") {
+		return FormatEnv, true
+	}
+	switch lang {
+	case "properties":
+		return FormatProperties, true
+	case "ini":
+		return FormatINI, true
+	case "yaml":
+		return FormatYAML, true
+	case "toml":
+		return FormatTOML, true
+	case "json":
+		return FormatJSON, true
+	}
+	return "", false
+}
+
+// MaskValues replaces the values in a configuration file with placeholders
+// describing their type, such as <int> or <string:32 chars>, while keeping
+// keys, nesting, sections and comments. Values spanning several lines are
+// masked on the first of them; the LineMap returned tells where the
+// remaining lines come from.
+func MaskValues(src []byte, format ConfigFormat) ([]byte, LineMap) {
+	text := string(src)
+	switch format {
+	case FormatJSON:
+		return []byte(maskFlow(text, 0)), nil
+	case FormatEnv:
+		return maskLines(text, maskEnvLine)
+	case FormatProperties:
+		return maskLines(text, maskPropertiesLine)
+	case FormatINI:
+		return maskLines(text, maskINILine)
+	case FormatTOML:
+		return maskLines(text, maskTOMLLine)
+	case FormatYAML:
+		return maskLines(text, maskYAMLLine)
+	}
+	return src, nil
+}
+
+// lineMasker masks the entry starting at lines[i], which may continue on
+// the following lines. It returns the masked text, without a trailing
+// newline, and the number of lines consumed.
+type lineMasker func(lines []string, i int) (string, int)
+
+func maskLines(text string, mask lineMasker) ([]byte, LineMap) {
+	lines := strings.SplitAfter(text, "\n")
+	// Maskers see lines without their endings, which are put back afterwards.
+	bare := make([]string, len(lines))
+	for i, line := range lines {
+		bare[i] = strings.TrimSuffix(line, lineEnding(line))
+	}
+
+	var b strings.Builder
+	b.Grow(len(text))
+	lineMap := newLineMapper()
+	for i := 0; i < len(lines); {
+		masked, n := mask(bare, i)
+		b.WriteString(masked)
+		b.WriteString(lineEnding(lines[i+n-1]))
+		for range n - 1 {
+			lineMap.newline(false)
+		}
+		lineMap.newline(true)
+		i += n
+	}
+	out := []byte(b.String())
+	return out, lineMap.lineMap(CountLines(out))
+}
+
+func lineEnding(line string) string {
+	switch {
+	case strings.HasSuffix(line, "\r\n"):
+		return "\r\n"
+	case strings.HasSuffix(line, "\n"):
+		return "\n"
+	}
+	return ""
+}
+
+var envAssignment = regexp.MustCompile(`^(\s*(?:export\s+)?[A-Za-z_][A-Za-z0-9_.-]*\s*=\s*)(.*)$`)
+
+func maskEnvLine(lines []string, i int) (string, int) {
+	m := envAssignment.FindStringSubmatch(lines[i])
+	if m == nil {
+		return lines[i], 1
+	}
+	value, n := joinQuoted(lines, i, m[2])
+	value, comment := splitComment(value, "#")
+	return m[1] + placeholder(value) + comment, n
+}
+
+// joinQuoted extends a value that opens a quote it does not close on its
+// own line with the following lines, up to the closing quote.
+func joinQuoted(lines []string, i int, value string) (string, int) {
+	if value == "" || (value[0] != '"' && value[0] != '\'') || quoteEnd(value, 0) >= 0 {
+		return value, 1
+	}
+	n := 1
+	for i+n < len(lines) {
+		value += "\n" + lines[i+n]
+		n++
+		if quoteEnd(value, 0) >= 0 {
+			break
+		}
+	}
+	return value, n
+}
+
+func maskPropertiesLine(lines []string, i int) (string, int) {
+	line := lines[i]
+	trimmed := strings.TrimLeft(line, " \t\f")
+	if trimmed == "" || trimmed[0] == '#' || trimmed[0] == '!' {
+		return line, 1
+	}
+	// The key ends at the first unescaped separator.
+	keyEnd := len(line) - len(trimmed)
+	for keyEnd < len(line) && !strings.ContainsRune("=: \t\f", rune(line[keyEnd])) {
+		if line[keyEnd] == '\\' {
+			keyEnd++
+		}
+		keyEnd++
+	}
+	keyEnd = min(keyEnd, len(line))
+	valueStart := keyEnd
+	for valueStart < len(line) && strings.ContainsRune(" \t\f", rune(line[valueStart])) {
+		valueStart++
+	}
+	if valueStart < len(line) && (line[valueStart] == '=' || line[valueStart] == ':') {
+		valueStart++
+	}
+	for valueStart < len(line) && strings.ContainsRune(" \t\f", rune(line[valueStart])) {
+		valueStart++
+	}
+
+	value, n := line[valueStart:], 1
+	for continues(value) && i+n < len(lines) {
+		value = value[:len(value)-1] + strings.TrimLeft(lines[i+n], " \t\f")
+		n++
+	}
+	return line[:valueStart] + placeholder(value), n
+}
+
+// continues reports whether a properties value ends with an unescaped backslash.
+func continues(value string) bool {
+	slashes := len(value) - len(strings.TrimRight(value, `\`))
+	return slashes%2 == 1
+}
+
+func maskINILine(lines []string, i int) (string, int) {
+	line := lines[i]
+	trimmed := strings.TrimSpace(line)
+	if trimmed == "" || trimmed[0] == ';' || trimmed[0] == '#' || trimmed[0] == '[' {
+		return line, 1
+	}
+	sep := strings.IndexAny(line, "=:")
+	if sep < 0 {
+		return line, 1
+	}
+	valueStart := sep + 1
+	for valueStart < len(line) && (line[valueStart] == ' ' || line[valueStart] == '\t') {
+		valueStart++
+	}
+	value, comment := splitComment(line[valueStart:], ";#")
+	return line[:valueStart] + placeholder(value) + comment, 1
+}
+
+func maskTOMLLine(lines []string, i int) (string, int) {
+	line := lines[i]
+	trimmed := strings.TrimSpace(line)
+	if trimmed == "" || trimmed[0] == '#' || trimmed[0] == '[' {
+		return line, 1
+	}
+	sep := indexOutsideQuotes(line, '=')
+	if sep < 0 {
+		return line, 1
+	}
+	valueStart := sep + 1
+	for valueStart < len(line) && (line[valueStart] == ' ' || line[valueStart] == '\t') {
+		valueStart++
+	}
+	value := line[valueStart:]
+	prefix := line[:valueStart]
+
+	// Multi-line strings.
+	for _, delim := range []string{`"""`, `'''`} {
+		if !strings.HasPrefix(value, delim) {
+			continue
+		}
+		n := 1
+		rest := value[len(delim):]
+		for !strings.Contains(rest, delim) && i+n < len(lines) {
+			rest += "\n" + lines[i+n]
+			n++
+		}
+		end := strings.Index(rest, delim)
+		if end < 0 {
+			end = len(rest)
+		}
+		body := strings.TrimPrefix(rest[:end], "\n")
+		tail := ""
+		if end+len(delim) <= len(rest) {
+			tail = rest[end+len(delim):]
+		}
+		return prefix + delim + stringPlaceholder(body) + delim + tail, n
+	}
+
+	// Arrays and inline tables may span several lines.
+	n := 1
+	if value != "" && (value[0] == '[' || value[0] == '{') {
+		for depth(value) > 0 && i+n < len(lines) {
+			value += "\n" + lines[i+n]
+			n++
+		}
+	}
+	return prefix + maskFlow(value, '#'), n
+}
+
+var yamlKey = regexp.MustCompile(`^((?:"(?:[^"\\]|\\.)*"|'(?:[^']|'')*'|[^\s#'"][^#]*?)\s*:)(\s+|$)`)
+
+func maskYAMLLine(lines []string, i int) (string, int) {
+	line := lines[i]
+	trimmed := strings.TrimSpace(line)
+	if trimmed == "" || trimmed[0] == '#' || trimmed == "---" || trimmed == "..." || strings.HasPrefix(trimmed, "%") {
+		return line, 1
+	}
+
+	indent := len(line) - len(strings.TrimLeft(line, " "))
+	pos := indent
+	// Sequence entries, possibly nested on one line.
+	for strings.HasPrefix(line[pos:], "- ") || line[pos:] == "-" {
+		pos += 1
+		for pos < len(line) && line[pos] == ' ' {
+			pos++
+		}
+	}
+	isEntry := pos > indent
+	if m := yamlKey.FindStringSubmatch(line[pos:]); m != nil {
+		pos += len(m[0])
+	} else if !isEntry {
+		// A plain scalar continued from the line above.
+		value, comment := splitComment(line[indent:], "#")
+		return line[:indent] + placeholder(value) + comment, 1
+	}
+
+	value := line[pos:]
+	prefix := line[:pos]
+	// Anchors and tags are kept, aliases have no value of their own.
+	for strings.HasPrefix(value, "&") || strings.HasPrefix(value, "!") {
+		end := strings.IndexAny(value, " \t")
+		if end < 0 {
+			return line, 1
+		}
+		for end < len(value) && (value[end] == ' ' || value[end] == '\t') {
+			end++
+		}
+		prefix += value[:end]
+		value = value[end:]
+	}
+	if value == "" || value[0] == '*' || value[0] == '#' {
+		return line, 1
+	}
+
+	// Block scalars take all following lines that are more indented.
+	if value[0] == '|' || value[0] == '>' {
+		n := 1
+		var body []string
+		for i+n < len(lines) {
+			next := lines[i+n]
+			nextIndent := len(next) - len(strings.TrimLeft(next, " "))
+			if strings.TrimSpace(next) != "" && nextIndent <= indent {
+				break
+			}
+			body = append(body, strings.TrimSpace(next))
+			n++
+		}
+		return prefix + stringPlaceholder(strings.TrimSpace(strings.Join(body, "\n"))), n
+	}
+
+	n := 1
+	if value[0] == '[' || value[0] == '{' {
+		for depth(value) > 0 && i+n < len(lines) {
+			value += "\n" + lines[i+n]
+			n++
+		}
+		return prefix + maskFlow(value, '#'), n
+	}
+	value, comment := splitComment(value, "#")
+	return prefix + placeholder(value) + comment, n
+}
+
+// maskFlow masks the scalars of JSON, YAML flow collections and TOML
+// arrays and inline tables, keeping keys and punctuation. If comment is not
+// zero, it starts a comment that runs to the end of the line.
+func maskFlow(s string, comment byte) string {
+	var b strings.Builder
+	b.Grow(len(s))
+	for i := 0; i < len(s); {
+		c := s[i]
+		switch {
+		case strings.IndexByte(" \t\r\n[]{},:=", c) >= 0:
+			b.WriteByte(c)
+			i++
+		case comment != 0 && c == comment:
+			end := strings.IndexByte(s[i:], '\n')
+			if end < 0 {
+				end = len(s) - i
+			}
+			b.WriteString(s[i : i+end])
+			i += end
+		case c == '"' || c == '\'':
+			end := quoteEnd(s, i)
+			if end < 0 {
+				end = len(s)
+			} else {
+				end++
+			}
+			writeScalar(&b, s, i, end)
+			i = end
+		default:
+			end := i
+			for end < len(s) && strings.IndexByte(",]}\n=", s[end]) < 0 &&
+				!(s[end] == ':' && (end+1 == len(s) || strings.IndexByte(" \t\r\n", s[end+1]) >= 0)) &&
+				!(comment != 0 && s[end] == comment && s[end-1] == ' ') {
+				end++
+			}
+			token := strings.TrimRight(s[i:end], " \t\r")
+			writeScalar(&b, s, i, i+len(token))
+			i += len(token)
+		}
+	}
+	return b.String()
+}
+
+// writeScalar writes s[start:end] as it is if it is a key, that is followed
+// by a colon or an equals sign, and as a placeholder otherwise.
+func writeScalar(b *strings.Builder, s string, start, end int) {
+	next := strings.TrimLeft(s[end:], " \t")
+	if next != "" && (next[0] == ':' || next[0] == '=') {
+		b.WriteString(s[start:end])
+		return
+	}
+	b.WriteString(placeholder(s[start:end]))
+}
+
+var (
+	intPattern   = regexp.MustCompile(`^[-+]?(0|[1-9][0-9_]*|0x[0-9a-fA-F_]+|0o[0-7_]+|0b[01_]+)$`)
+	floatPattern = regexp.MustCompile(`^[-+]?([0-9][0-9_]*)?\.?[0-9_]+([eE][-+]?[0-9]+)?$|^[-+]?(inf|nan|\.inf|\.nan)$`)
+	datePattern  = regexp.MustCompile(`^\d{4}-\d{2}-\d{2}([T ]\d{2}:\d{2}(:\d{2}(\.\d+)?)?(Z|[-+]\d{2}:?\d{2})?)?$`)
+)
+
+// placeholder describes the type of a raw scalar value without revealing it.
+// Quotes around string values are kept and empty values stay empty.
+func placeholder(raw string) string {
+	raw = strings.TrimSpace(raw)
+	if raw == "" {
+		return raw
+	}
+	if q := raw[0]; (q == '"' || q == '\'') && len(raw) >= 2 && raw[len(raw)-1] == q {
+		return string(q) + stringPlaceholder(raw[1:len(raw)-1]) + string(q)
+	}
+	switch strings.ToLower(raw) {
+	case "true", "false", "yes", "no", "on", "off":
+		return "<bool>"
+	case "null", "~", "nil", "none":
+		return "<null>"
+	}
+	switch {
+	case intPattern.MatchString(raw):
+		return "<int>"
+	case floatPattern.MatchString(raw):
+		return "<float>"
+	case datePattern.MatchString(raw):
+		return "<date>"
+	}
+	return stringPlaceholder(raw)
+}
+
+func stringPlaceholder(s string) string {
+	n := utf8.RuneCountInString(s)
+	if n == 1 {
+		return "<string:1 char>"
+	}
+	return "<string:" + strconv.Itoa(n) + " chars>"
+}
+
+// splitComment separates a trailing comment, started by one of markers
+// after whitespace and outside quotes, from value.
+func splitComment(value, markers string) (string, string) {
+	for i := 0; i < len(value); i++ {
+		switch c := value[i]; {
+		case c == '"' || c == '\'':
+			if end := quoteEnd(value, i); end >= 0 {
+				i = end
+			}
+		case strings.IndexByte(markers, c) >= 0 && (i == 0 || value[i-1] == ' ' || value[i-1] == '\t'):
+			start := i
+			for start > 0 && (value[start-1] == ' ' || value[start-1] == '\t') {
+				start--
+			}
+			return value[:start], value[start:]
+		}
+	}
+	return value, ""
+}
+
+// quoteEnd returns the index of the quote closing the one at s[start], or -1.
+func quoteEnd(s string, start int) int {
+	q := s[start]
+	for i := start + 1; i < len(s); i++ {
+		if q == '"' && s[i] == '\\' {
+			i++
+			continue
+		}
+		if s[i] == q {
+			return i
+		}
+	}
+	return -1
+}
+
+func indexOutsideQuotes(s string, c byte) int {
+	for i := 0; i < len(s); i++ {
+		switch s[i] {
+		case '"', '\'':
+			end := quoteEnd(s, i)
+			if end < 0 {
+				return -1
+			}
+			i = end
+		case c:
+			return i
+		}
+	}
+	return -1
+}
+
+// depth returns how many brackets and braces s leaves open.
+func depth(s string) int {
+	d := 0
+	for i := 0; i < len(s); i++ {
+		switch s[i] {
+		case '"', '\'':
+			end := quoteEnd(s, i)
+			if end < 0 {
+				return d
+			}
+			i = end
+		case '[', '{':
+			d++
+		case ']', '}':
+			d--
+		case '#':
+			if end := strings.IndexByte(s[i:], '\n'); end >= 0 {
+				i += end
+			} else {
+				return d
+			}
+		}
+	}
+	return d
+}
@@ -0,0 +1,93 @@
+package transform
+
+import (
+	"testing"
+)
+
+func TestMaskValues(t *testing.T) {
+	testCases := []struct {
+		name     string
+		format   ConfigFormat
+		input    string
+		expected string
+	}{
+		{
+			name:     "Env",
+			format:   FormatEnv,
+			input:    "# Database\nexport DB_HOST=db.internal\nDB_PORT=5432\nDB_PASSWORD=\"hunter2\" # rotate\nEMPTY=\n",
+			expected: "# Database\nexport DB_HOST=<string:11 chars>\nDB_PORT=<int>\nDB_PASSWORD=\"<string:7 chars>\" # rotate\nEMPTY=\n",
+		},
+		{
+			name:     "Env multi-line value",
+			format:   FormatEnv,
+			input:    "KEY=\"line1\nline2\"\nNEXT=1\n",
+			expected: "KEY=\"<string:11 chars>\"\nNEXT=<int>\n",
+		},
+		{
+			name:     "Env single character",
+			format:   FormatEnv,
+			input:    "MODE=x\nNAME=\"é\"\nPAIR=xy\n",
+			expected: "MODE=<string:1 char>\nNAME=\"<string:1 char>\"\nPAIR=<string:2 chars>\n",
+		},
+		{
+			name:     "Properties",
+			format:   FormatProperties,
+			input:    "! comment\nserver.port=8080\nserver.name : api\nlong = a\\\n  b\n",
+			expected: "! comment\nserver.port=<int>\nserver.name : <string:3 chars>\nlong = <string:2 chars>\n",
+		},
+		{
+			name:     "INI",
+			format:   FormatINI,
+			input:    "[db]\nhost = localhost ; local\nratio: 0.5\n",
+			expected: "[db]\nhost = <string:9 chars> ; local\nratio: <float>\n",
+		},
+		{
+			name:     "TOML",
+			format:   FormatTOML,
+			input:    "title = \"App\"\n[server]\nport = 80 # http\nhosts = [\n  \"a\",\n  \"b\",\n]\nopts = { debug = true, level = 3 }\nsince = 2024-01-02\ntext = \"\"\"\nhello\n\"\"\"\n",
+			expected: "title = \"<string:3 chars>\"\n[server]\nport = <int> # http\nhosts = [\n  \"<string:1 char>\",\n  \"<string:1 char>\",\n]\nopts = { debug = <bool>, level = <int> }\nsince = <date>\ntext = \"\"\"<string:6 chars>\"\"\"\n",
+		},
+		{
+			name:     "YAML",
+			format:   FormatYAML,
+			input:    "server:\n  host: example.com # public\n  ports:\n    - 80\n    - 443\n  tls: true\n  key: |\n    abc\n    def\nbase: &base\n  name: 'x'\nother: *base\nlist: [a, 1]\n",
+			expected: "server:\n  host: <string:11 chars> # public\n  ports:\n    - <int>\n    - <int>\n  tls: <bool>\n  key: <string:7 chars>\nbase: &base\n  name: '<string:1 char>'\nother: *base\nlist: [<string:1 char>, <int>]\n",
+		},
+		{
+			name:     "JSON",
+			format:   FormatJSON,
+			input:    "{\n  \"name\": \"dirmd\",\n  \"version\": 2,\n  \"tags\": [\"a\", null],\n  \"nested\": {\"ok\": false, \"ratio\": 1.5}\n}\n",
+			expected: "{\n  \"name\": \"<string:5 chars>\",\n  \"version\": <int>,\n  \"tags\": [\"<string:1 char>\", <null>],\n  \"nested\": {\"ok\": <bool>, \"ratio\": <float>}\n}\n",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			got, _ := MaskValues([]byte(tc.input), tc.format)
+			if string(got) != tc.expected {
+				t.Errorf("MaskValues() mismatch:\n--- EXPECTED ---\n%q\n\n--- GOT ---\n%q", tc.expected, got)
+			}
+		})
+	}
+}
+
+func TestDetectConfigFormat(t *testing.T) {
+	testCases := []struct {
+		path     string
+		lang     string
+		expected ConfigFormat
+		ok       bool
+	}{
+		{".env", "env", FormatEnv, true},
+		{"deploy/.env.production", "production", FormatEnv, true},
+		{"config/app.yaml", "yaml", FormatYAML, true},
+		{"Cargo.toml", "toml", FormatTOML, true},
+		{"main.go", "go", "", false},
+	}
+	for _, tc := range testCases {
+		format, ok := DetectConfigFormat(tc.path, tc.lang)
+		if format != tc.expected || ok != tc.ok {
+			t.Errorf("DetectConfigFormat(%q) = %q, %v; want %q, %v", tc.path, format, ok, tc.expected, tc.ok)
+		}
+	}
+}
@@ -0,0 +1,267 @@
+package transform
+
+import (
+	"bytes"
+	"fmt"
+	"regexp"
+	"strconv"
+	"unicode/utf8"
+)
+
+// TruncateStrategy selects which lines of an oversized file are kept.
+type TruncateStrategy string
+
+const (
+	// TruncateHead keeps the beginning of the file.
+	TruncateHead TruncateStrategy = "head"
+	// TruncateHeadTail keeps the beginning and the end of the file.
+	TruncateHeadTail TruncateStrategy = "head-tail"
+	// TruncateHeadTailMatch keeps the beginning and the end of the file,
+	// plus the lines in between that match a pattern.
+	TruncateHeadTailMatch TruncateStrategy = "head-tail-match"
+)
+
+// ParseTruncateStrategy validates the name of a truncation strategy.
+func ParseTruncateStrategy(name string) (TruncateStrategy, error) {
+	switch s := TruncateStrategy(name); s {
+	case TruncateHead, TruncateHeadTail, TruncateHeadTailMatch:
+		return s, nil
+	}
+	return "", fmt.Errorf("unknown truncation strategy %q (want %s, %s or %s)", name, TruncateHead, TruncateHeadTail, TruncateHeadTailMatch)
+}
+
+// Limits bounds the size of a file's content. A zero limit is not enforced.
+type Limits struct {
+	MaxLines int
+	MaxBytes int
+	Strategy TruncateStrategy
+	// Match selects the extra lines kept by TruncateHeadTailMatch.
+	Match *regexp.Regexp
+}
+
+func (l Limits) enabled() bool {
+	return l.MaxLines > 0 || l.MaxBytes > 0
+}
+
+// Region is a run of consecutive lines kept from a file.
+type Region struct {
+	// FirstLine is the line number of the region's first line, counting from 1.
+	FirstLine int
+	Content   []byte
+	// Cut is the number of bytes cut from the end of the region's last line
+	// to fit a byte limit.
+	Cut int
+}
+
+// Truncate selects the lines of src to keep under limits. If src is within
+// limits, it is returned as a single region and truncated is false.
+func Truncate(src []byte, limits Limits) (regions []Region, truncated bool) {
+	lines := splitLines(src)
+	whole := []Region{{FirstLine: 1, Content: src}}
+	if !limits.enabled() || (limits.MaxLines <= 0 || len(lines) <= limits.MaxLines) && (limits.MaxBytes <= 0 || len(src) <= limits.MaxBytes) {
+		return whole, false
+	}
+
+	parts := 1
+	switch limits.Strategy {
+	case TruncateHeadTail:
+		parts = 2
+	case TruncateHeadTailMatch:
+		parts = 2
+		if limits.Match != nil {
+			parts = 3
+		}
+	}
+	share := func() *budget {
+		return &budget{
+			lines:   max(1, limits.MaxLines/parts),
+			bytes:   limits.MaxBytes / parts,
+			linesOn: limits.MaxLines > 0,
+			bytesOn: limits.MaxBytes > 0,
+		}
+	}
+
+	keep := make([]bool, len(lines))
+	head := share()
+	first := 0
+	for ; first < len(lines) && head.take(lines[first]); first++ {
+		keep[first] = true
+	}
+	// A first line over the whole budget is cut short rather than left out,
+	// which would leave nothing of the file.
+	var cut *Region
+	if first == 0 && head.bytesOn && head.bytes > 0 {
+		n := head.bytes
+		for n > 0 && !utf8.RuneStart(lines[0][n]) {
+			n--
+		}
+		if n > 0 {
+			cut = &Region{FirstLine: 1, Content: lines[0][:n], Cut: len(lines[0]) - n}
+			first = 1
+		}
+	}
+	last := len(lines)
+	if parts > 1 {
+		tail := share()
+		for last > first && tail.take(lines[last-1]) {
+			last--
+			keep[last] = true
+		}
+	}
+	if parts > 2 && limits.Match != nil {
+		matches := share()
+		for i := first; i < last; i++ {
+			if limits.Match.Match(lines[i]) && matches.take(lines[i]) {
+				keep[i] = true
+			}
+		}
+	}
+
+	regions = keptRegions(lines, keep)
+	if cut != nil {
+		regions = append([]Region{*cut}, regions...)
+	}
+	return regions, true
+}
+
+// keptRegions groups the runs of lines marked in keep into regions.
+func keptRegions(lines [][]byte, keep []bool) []Region {
+	var regions []Region
+	for i := 0; i < len(lines); i++ {
+		if !keep[i] {
+			continue
+		}
+		start := i
+		var content []byte
+		for ; i < len(lines) && keep[i]; i++ {
+			content = append(content, lines[i]...)
+		}
+		regions = append(regions, Region{FirstLine: start + 1, Content: content})
+	}
+	return regions
+}
+
+// budget tracks how many lines and bytes may still be taken.
+type budget struct {
+	lines, bytes     int
+	linesOn, bytesOn bool
+}
+
+func (b *budget) take(line []byte) bool {
+	if (b.linesOn && b.lines < 1) || (b.bytesOn && b.bytes < len(line)) {
+		return false
+	}
+	b.lines--
+	b.bytes -= len(line)
+	return true
+}
+
+// CountLines returns the number of lines in src. A final line without a
+// newline counts as a line.
+func CountLines(src []byte) int {
+	n := bytes.Count(src, []byte("\n"))
+	if len(src) > 0 && src[len(src)-1] != '\n' {
+		n++
+	}
+	return n
+}
+
+// ElisionMarker returns the line that stands in for n omitted lines.
+func ElisionMarker(n int) string {
+	if n == 1 {
+		return "... [1 line omitted] ..."
+	}
+	return "... [" + strconv.Itoa(n) + " lines omitted] ..."
+}
+
+// CutMarker returns the text that stands in for n bytes cut from the end of
+// a line.
+func CutMarker(n int) string {
+	if n == 1 {
+		return "... [1 byte omitted] ..."
+	}
+	return "... [" + strconv.Itoa(n) + " bytes omitted] ..."
+}
+
+// JoinOptions controls how JoinRegions renders regions.
+type JoinOptions struct {
+	// LineNumbers prefixes every kept line with its number.
+	LineNumbers bool
+	// LineNumberFormat is passed on to NumberLines.
+	LineNumberFormat string
+	// Relative numbers kept lines from 1 instead of by their line in the file.
+	Relative bool
+	// Marked holds the numbers of the lines in the file to mark, see MarkLines.
+	Marked map[int]bool
+	// LineMap, if the regions were taken from a transformed file, numbers
+	// and marks lines by the line of the file they come from.
+	LineMap LineMap
+}
+
+// JoinRegions renders regions taken from a file of totalLines lines,
+// replacing each gap between them with an elision marker.
+func JoinRegions(regions []Region, totalLines int, opts JoinOptions) []byte {
+	width := 0
+	if opts.LineNumbers && !opts.Relative {
+		width = len(strconv.Itoa(opts.LineMap.Line(totalLines)))
+	} else if opts.LineNumbers {
+		kept := 0
+		for _, r := range regions {
+			kept += CountLines(r.Content)
+		}
+		width = len(strconv.Itoa(kept))
+	}
+
+	var out []byte
+	next, numbered := 1, 1
+	for _, r := range regions {
+		if r.FirstLine > next {
+			out = appendMarker(out, ElisionMarker(r.FirstLine-next))
+		}
+		next = r.FirstLine + CountLines(r.Content)
+		if !opts.LineNumbers && len(opts.Marked) == 0 {
+			out = append(out, r.Content...)
+			if r.Cut > 0 {
+				out = appendMarker(out, CutMarker(r.Cut))
+			}
+			continue
+		}
+		for _, run := range opts.LineMap.split(r) {
+			content := run.Content
+			if opts.LineNumbers {
+				first := run.FirstLine
+				if opts.Relative {
+					first = numbered
+				}
+				content = NumberLines(content, first, width, opts.LineNumberFormat)
+			}
+			if len(opts.Marked) > 0 {
+				content = MarkLines(content, run.FirstLine, opts.Marked)
+			}
+			out = append(out, content...)
+			numbered += CountLines(run.Content)
+		}
+		if r.Cut > 0 {
+			out = appendMarker(out, CutMarker(r.Cut))
+		}
+	}
+	if next <= totalLines {
+		out = appendMarker(out, ElisionMarker(totalLines-next+1))
+	}
+	return out
+}
+
+func appendMarker(out []byte, marker string) []byte {
+	if len(out) > 0 && out[len(out)-1] != '\n' {
+		out = append(out, '\n')
+	}
+	return append(append(out, marker...), '\n')
+}
+
+func splitLines(src []byte) [][]byte {
+	var lines [][]byte
+	for line := range bytes.Lines(src) {
+		lines = append(lines, line)
+	}
+	return lines
+}
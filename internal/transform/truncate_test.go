@@ -0,0 +1,106 @@
+package transform
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+func numberedLines(n int) string {
+	var b strings.Builder
+	for i := 1; i <= n; i++ {
+		fmt.Fprintf(&b, "line %d\n", i)
+	}
+	return b.String()
+}
+
+func TestTruncate(t *testing.T) {
+	testCases := []struct {
+		name      string
+		input     string
+		limits    Limits
+		opts      JoinOptions
+		truncated bool
+		expected  string
+	}{
+		{
+			name:     "Within limits",
+			input:    numberedLines(3),
+			limits:   Limits{MaxLines: 3, Strategy: TruncateHead},
+			expected: numberedLines(3),
+		},
+		{
+			name:      "Head",
+			input:     numberedLines(10),
+			limits:    Limits{MaxLines: 2, Strategy: TruncateHead},
+			truncated: true,
+			expected:  "line 1\nline 2\n... [8 lines omitted] ...\n",
+		},
+		{
+			name:      "Head and tail",
+			input:     numberedLines(10),
+			limits:    Limits{MaxLines: 4, Strategy: TruncateHeadTail},
+			truncated: true,
+			expected:  "line 1\nline 2\n... [6 lines omitted] ...\nline 9\nline 10\n",
+		},
+		{
+			name:      "Head, tail and matches",
+			input:     numberedLines(10),
+			limits:    Limits{MaxLines: 3, Strategy: TruncateHeadTailMatch, Match: regexp.MustCompile(`line 5`)},
+			truncated: true,
+			expected:  "line 1\n... [3 lines omitted] ...\nline 5\n... [4 lines omitted] ...\nline 10\n",
+		},
+		{
+			name:      "Byte limit",
+			input:     numberedLines(10),
+			limits:    Limits{MaxBytes: 15, Strategy: TruncateHead},
+			truncated: true,
+			expected:  "line 1\nline 2\n... [8 lines omitted] ...\n",
+		},
+		{
+			name:      "Byte limit within the first line",
+			input:     "const data = \"0123456789\"\nline 2\n",
+			limits:    Limits{MaxBytes: 16, Strategy: TruncateHead},
+			truncated: true,
+			expected:  "const data = \"01\n... [10 bytes omitted] ...\n... [1 line omitted] ...\n",
+		},
+		{
+			name:      "Byte limit within a single line",
+			input:     "x := \"héllo, world\"",
+			limits:    Limits{MaxBytes: 16, Strategy: TruncateHeadTail},
+			opts:      JoinOptions{LineNumbers: true},
+			truncated: true,
+			expected:  "1 | x := \"h\n... [13 bytes omitted] ...\n",
+		},
+		{
+			name:      "Line numbers use the real offset",
+			input:     numberedLines(10),
+			limits:    Limits{MaxLines: 2, Strategy: TruncateHeadTail},
+			opts:      JoinOptions{LineNumbers: true},
+			truncated: true,
+			expected:  " 1 | line 1\n... [8 lines omitted] ...\n10 | line 10\n",
+		},
+		{
+			name:      "Relative line numbers",
+			input:     numberedLines(10),
+			limits:    Limits{MaxLines: 2, Strategy: TruncateHeadTail},
+			opts:      JoinOptions{LineNumbers: true, Relative: true},
+			truncated: true,
+			expected:  "1 | line 1\n... [8 lines omitted] ...\n2 | line 10\n",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			regions, truncated := Truncate([]byte(tc.input), tc.limits)
+			if truncated != tc.truncated {
+				t.Errorf("Truncate() truncated = %v; want %v", truncated, tc.truncated)
+			}
+			got := string(JoinRegions(regions, CountLines([]byte(tc.input)), tc.opts))
+			if got != tc.expected {
+				t.Errorf("mismatch:\n--- EXPECTED ---\n%q\n\n--- GOT ---\n%q", tc.expected, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,120 @@
+package transform
+
+import (
+	"bytes"
+)
+
+// indentationSensitive lists languages in which rewriting leading
+// whitespace can change the meaning of a file.
+var indentationSensitive = map[string]bool{
+	"coffeescript": true,
+	"elm":          true,
+	"fsharp":       true,
+	"haskell":      true,
+	"makefile":     true,
+	"markdown":     true,
+	"python":       true,
+	"sass":         true,
+	"yaml":         true,
+}
+
+// IsIndentationSensitive reports whether indentation is significant in lang.
+func IsIndentationSensitive(lang string) bool {
+	return indentationSensitive[lang]
+}
+
+// trailingWhitespaceSensitive lists languages in which blanks at the end of
+// a line can carry meaning, such as the two spaces of a Markdown hard break.
+var trailingWhitespaceSensitive = map[string]bool{
+	"markdown": true,
+}
+
+// IsTrailingWhitespaceSensitive reports whether trailing whitespace is
+// significant in lang.
+func IsTrailingWhitespaceSensitive(lang string) bool {
+	return trailingWhitespaceSensitive[lang]
+}
+
+// NormalizeNewlines converts CRLF line endings to LF.
+func NormalizeNewlines(src []byte) []byte {
+	return bytes.ReplaceAll(src, []byte("\r\n"), []byte("\n"))
+}
+
+// TrimTrailingWhitespace removes spaces and tabs at the end of every line.
+// A carriage return ending a CRLF line is kept.
+func TrimTrailingWhitespace(src []byte) []byte {
+	out := make([]byte, 0, len(src))
+	for line := range bytes.Lines(src) {
+		body, eol := splitEOL(line)
+		out = append(out, bytes.TrimRight(body, " \t")...)
+		out = append(out, eol...)
+	}
+	return out
+}
+
+// CollapseBlankLines replaces every run of blank lines with a single empty
+// line. It returns the LineMap of the lines it removed.
+func CollapseBlankLines(src []byte) ([]byte, LineMap) {
+	out := make([]byte, 0, len(src))
+	lines := newLineMapper()
+	blank := false
+	for line := range bytes.Lines(src) {
+		body, eol := splitEOL(line)
+		if len(bytes.TrimSpace(body)) == 0 {
+			if blank {
+				lines.newline(false)
+				lines.drop()
+				continue
+			}
+			blank = true
+			out = append(out, eol...)
+		} else {
+			blank = false
+			out = append(out, line...)
+		}
+		if len(eol) > 0 {
+			lines.newline(true)
+		}
+	}
+	return out, lines.lineMap(CountLines(out))
+}
+
+// IndentWithTabs rewrites the leading whitespace of every line using tabs,
+// treating width columns as one level of indentation. Columns that do not
+// fill a whole level are kept as spaces. Lines that start inside a string
+// literal or heredoc of lang are left alone, as their leading whitespace is
+// part of it.
+func IndentWithTabs(src []byte, width int, lang string) []byte {
+	if width <= 0 {
+		return src
+	}
+	literal := literalLines(src, lang)
+	out := make([]byte, 0, len(src))
+	for n, line := range splitLines(src) {
+		if literal[n] {
+			out = append(out, line...)
+			continue
+		}
+		column, i := 0, 0
+		for ; i < len(line); i++ {
+			if line[i] == ' ' {
+				column++
+			} else if line[i] == '\t' {
+				column += width - column%width
+			} else {
+				break
+			}
+		}
+		out = append(out, bytes.Repeat([]byte{'\t'}, column/width)...)
+		out = append(out, bytes.Repeat([]byte{' '}, column%width)...)
+		out = append(out, line[i:]...)
+	}
+	return out
+}
+
+// splitEOL separates a line from its line ending.
+func splitEOL(line []byte) (body, eol []byte) {
+	body = bytes.TrimSuffix(line, []byte("\n"))
+	body = bytes.TrimSuffix(body, []byte("\r"))
+	return body, line[len(body):]
+}
@@ -0,0 +1,63 @@
+package transform
+
+import (
+	"testing"
+)
+
+func TestWhitespaceTransforms(t *testing.T) {
+	testCases := []struct {
+		name      string
+		transform func([]byte) []byte
+		input     string
+		expected  string
+	}{
+		{
+			name:      "Normalize CRLF",
+			transform: NormalizeNewlines,
+			input:     "a\r\nb\r\n",
+			expected:  "a\nb\n",
+		},
+		{
+			name:      "Trim trailing whitespace",
+			transform: TrimTrailingWhitespace,
+			input:     "a  \nb\t\r\n  \nc ",
+			expected:  "a\nb\r\n\nc",
+		},
+		{
+			name: "Collapse blank lines",
+			transform: func(src []byte) []byte {
+				out, _ := CollapseBlankLines(src)
+				return out
+			},
+			input:    "a\n\n\n  \nb\n\nc\n",
+			expected: "a\n\nb\n\nc\n",
+		},
+		{
+			name:      "Indent with tabs",
+			transform: func(src []byte) []byte { return IndentWithTabs(src, 4, "go") },
+			input:     "a\n    b\n      c\n\t  d\n",
+			expected:  "a\n\tb\n\t  c\n\t  d\n",
+		},
+		{
+			name:      "Indent with tabs keeps multi-line strings",
+			transform: func(src []byte) []byte { return IndentWithTabs(src, 4, "go") },
+			input:     "func f() {\n    s := `\n    raw\n    `\n    // `not raw\n    t := \"`\"\n}\n",
+			expected:  "func f() {\n\ts := `\n    raw\n    `\n\t// `not raw\n\tt := \"`\"\n}\n",
+		},
+		{
+			name:      "Indent with tabs keeps heredocs",
+			transform: func(src []byte) []byte { return IndentWithTabs(src, 4, "shell") },
+			input:     "if true; then\n    cat <<-EOF | sed 's/a/b/'\n    body\n\tEOF\n    cat <<'END' <<\"DONE\"\n    one\nEND\n    two\nDONE\n    echo $((1<<2))\n    x\nfi\n",
+			expected:  "if true; then\n\tcat <<-EOF | sed 's/a/b/'\n    body\n\tEOF\n\tcat <<'END' <<\"DONE\"\n    one\nEND\n    two\nDONE\n\techo $((1<<2))\n\tx\nfi\n",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			got := string(tc.transform([]byte(tc.input)))
+			if got != tc.expected {
+				t.Errorf("mismatch:\n--- EXPECTED ---\n%q\n\n--- GOT ---\n%q", tc.expected, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,153 @@
+// Package unicodescan finds characters that make source code read
+// differently from how it is compiled, as in Trojan Source attacks.
+package unicodescan
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Mode decides what happens when a suspicious character is found.
+type Mode string
+
+const (
+	// ModeOff disables the scan.
+	ModeOff Mode = "off"
+	// ModeWarn reports suspicious characters but bundles them unchanged.
+	ModeWarn Mode = "warn"
+	// ModeEscape replaces invisible and control characters with escapes such as \u202E.
+	ModeEscape Mode = "escape"
+	// ModeFail fails the run if any suspicious character is found.
+	ModeFail Mode = "fail"
+)
+
+// ParseMode validates the name of a scanning mode.
+func ParseMode(name string) (Mode, error) {
+	switch m := Mode(name); m {
+	case ModeOff, ModeWarn, ModeEscape, ModeFail:
+		return m, nil
+	}
+	return "", fmt.Errorf("unknown unicode check mode %q (want %s, %s, %s or %s)", name, ModeOff, ModeWarn, ModeEscape, ModeFail)
+}
+
+// Rule names.
+const (
+	RuleBidi      = "bidi-control"
+	RuleInvisible = "invisible-character"
+	RuleControl   = "control-character"
+	RuleHomoglyph = "mixed-script-identifier"
+)
+
+// Finding is a suspicious character or identifier.
+type Finding struct {
+	Rule string
+	// Line is the line the finding is on, counting from 1.
+	Line int
+	// Text is the offending character, escaped, or the offending identifier.
+	Text string
+}
+
+// classify returns the rule a character breaks, if any.
+func classify(r rune) string {
+	switch {
+	case r >= 0x202A && r <= 0x202E, r >= 0x2066 && r <= 0x2069, r == 0x200E, r == 0x200F, r == 0x061C:
+		return RuleBidi
+	case r >= 0x200B && r <= 0x200D, r == 0x2060, r == 0xFEFF, r == 0x00AD, r == 0x180E:
+		return RuleInvisible
+	case r == '\t' || r == '\n' || r == '\r' || r == '\f':
+		return ""
+	case r < 0x20, r >= 0x7F && r <= 0x9F:
+		return RuleControl
+	}
+	return ""
+}
+
+// Scan returns the suspicious characters and identifiers in content. A byte
+// order mark at the very start of content is not reported.
+func Scan(content []byte) []Finding {
+	var findings []Finding
+	line := 1
+	for i := 0; i < len(content); {
+		r, size := utf8.DecodeRune(content[i:])
+		if r == '\n' {
+			line++
+		}
+		if rule := classify(r); rule != "" && !(r == 0xFEFF && i == 0) {
+			findings = append(findings, Finding{Rule: rule, Line: line, Text: escape(r)})
+		}
+		if isIdentRune(r) {
+			j := i
+			for j < len(content) {
+				r, size := utf8.DecodeRune(content[j:])
+				if !isIdentRune(r) {
+					break
+				}
+				j += size
+			}
+			if ident := string(content[i:j]); mixesScripts(ident) {
+				findings = append(findings, Finding{Rule: RuleHomoglyph, Line: line, Text: ident})
+			}
+			i = j
+			continue
+		}
+		i += size
+	}
+	return findings
+}
+
+// Escape replaces bidi controls, invisible characters and control
+// characters in content with \u escapes. A leading byte order mark is kept.
+func Escape(content []byte) []byte {
+	var b strings.Builder
+	b.Grow(len(content))
+	for i := 0; i < len(content); {
+		r, size := utf8.DecodeRune(content[i:])
+		if classify(r) != "" && !(r == 0xFEFF && i == 0) {
+			b.WriteString(escape(r))
+		} else {
+			b.Write(content[i : i+size])
+		}
+		i += size
+	}
+	return []byte(b.String())
+}
+
+func escape(r rune) string {
+	return fmt.Sprintf(`\u%04X`, r)
+}
+
+func isIdentRune(r rune) bool {
+	return r == '_' || unicode.IsLetter(r) || unicode.IsDigit(r)
+}
+
+// confusableScripts are scripts with letters that look like Latin ones.
+var confusableScripts = []*unicode.RangeTable{unicode.Latin, unicode.Cyrillic, unicode.Greek, unicode.Armenian, unicode.Cherokee}
+
+// mixesScripts reports whether ident combines letters of several scripts
+// whose letters can be mistaken for one another.
+func mixesScripts(ident string) bool {
+	var seen *unicode.RangeTable
+	for _, r := range ident {
+		if r < utf8.RuneSelf {
+			if unicode.IsLetter(r) {
+				if seen != nil && seen != unicode.Latin {
+					return true
+				}
+				seen = unicode.Latin
+			}
+			continue
+		}
+		for _, script := range confusableScripts {
+			if !unicode.Is(script, r) {
+				continue
+			}
+			if seen != nil && seen != script {
+				return true
+			}
+			seen = script
+		}
+	}
+	return false
+}
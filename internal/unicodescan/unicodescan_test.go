@@ -0,0 +1,72 @@
+package unicodescan
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestScan(t *testing.T) {
+	testCases := []struct {
+		name     string
+		content  string
+		expected []Finding
+	}{
+		{
+			name:     "Plain code",
+			content:  "func main() {\n\tfmt.Println(\"héllo, 世界\")\n}\n",
+			expected: nil,
+		},
+		{
+			name:    "Bidi override in a comment",
+			content: "x := 1\n/*\u202E } \u2066if isAdmin\u2069 \u2066 begin admins only */\n",
+			expected: []Finding{
+				{Rule: RuleBidi, Line: 2, Text: `\u202E`},
+				{Rule: RuleBidi, Line: 2, Text: `\u2066`},
+				{Rule: RuleBidi, Line: 2, Text: `\u2069`},
+				{Rule: RuleBidi, Line: 2, Text: `\u2066`},
+			},
+		},
+		{
+			name:     "Zero-width space",
+			content:  "access\u200Blevel := 1\n",
+			expected: []Finding{{Rule: RuleInvisible, Line: 1, Text: `\u200B`}},
+		},
+		{
+			name:     "Leading byte order mark",
+			content:  "\uFEFFpackage main\n",
+			expected: nil,
+		},
+		{
+			name:     "Control character",
+			content:  "a\x1b[31m\n",
+			expected: []Finding{{Rule: RuleControl, Line: 1, Text: `\u001B`}},
+		},
+		{
+			name:     "Cyrillic letter in a Latin identifier",
+			content:  "if is\u0430dmin {\n",
+			expected: []Finding{{Rule: RuleHomoglyph, Line: 1, Text: "is\u0430dmin"}},
+		},
+		{
+			name:     "Cyrillic word",
+			content:  "// привет\n",
+			expected: nil,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			got := Scan([]byte(tc.content))
+			if !reflect.DeepEqual(got, tc.expected) {
+				t.Errorf("Scan() = %q; want %q", got, tc.expected)
+			}
+		})
+	}
+}
+
+func TestEscape(t *testing.T) {
+	input := "\uFEFFa\u202Eb\u200Dc\x07\n"
+	expected := "\uFEFFa\\u202Eb\\u200Dc\\u0007\n"
+	if got := string(Escape([]byte(input))); got != expected {
+		t.Errorf("Escape() = %q; want %q", got, expected)
+	}
+}
@@ -1,17 +1,36 @@
 package main
 
 import (
+	"context"
 	"fmt"
+	"io"
+	"maps"
 	"os"
+	"os/signal"
 	"path/filepath"
+	"slices"
+	"strings"
+	"syscall"
+	"text/tabwriter"
 
+	"github.com/axseem/dirmd/internal/anonymizer"
 	"github.com/axseem/dirmd/internal/bundler"
 	"github.com/axseem/dirmd/internal/config"
+	"github.com/axseem/dirmd/internal/transform"
 	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
 )
 
 func main() {
-	if err := newRootCmd().Execute(); err != nil {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	// Once a signal has cancelled the run, a second one kills the process
+	// as usual, in case something still blocks.
+	go func() {
+		<-ctx.Done()
+		stop()
+	}()
+	if err := newRootCmd().ExecuteContext(ctx); err != nil {
 		fmt.Fprintln(os.Stderr, err)
 		os.Exit(1)
 	}
@@ -19,45 +38,300 @@ func main() {
 
 func newRootCmd() *cobra.Command {
 	cfg := config.NewDefaultConfig()
+	var profile string
 
 	cmd := &cobra.Command{
-		Use:   "dirmd <directory>",
+		Use:   "dirmd [directory]",
 		Short: "Bundles all files from a directory into a single markdown file.",
 		Long: `dirmd is a CLI tool that traverses a specified directory,
 reads all non-ignored files, and bundles them into a single,
-well-formatted markdown file.`,
-		Args: cobra.ExactArgs(1),
+well-formatted markdown file.
+
+Without a directory, it bundles the root of the selected profile, or
+else the working directory.`,
+		Args: cobra.MaximumNArgs(1),
 		RunE: func(cmd *cobra.Command, args []string) error {
-			rootDir, err := filepath.Abs(args[0])
-			if err != nil {
-				return fmt.Errorf("invalid directory path: %w", err)
+			if _, err := loadConfig(cmd, args, cfg, profile); err != nil {
+				return err
+			}
+
+			if cfg.LineNumbers && !strings.Contains(cfg.LineNumberFormat, transform.LineNumberPlaceholder) {
+				return fmt.Errorf("line number format %q must contain %s", cfg.LineNumberFormat, transform.LineNumberPlaceholder)
 			}
-			info, err := os.Stat(rootDir)
+
+			b, err := bundler.New(cfg)
 			if err != nil {
-				return fmt.Errorf("cannot access directory: %w", err)
+				return err
 			}
-			if !info.IsDir() {
-				return fmt.Errorf("path is not a directory: %s", rootDir)
+
+			return b.Bundle(cmd.Context())
+		},
+	}
+
+	cmd.Flags().StringVarP(&profile, config.ProfileFlag, "p", "", "Name of the configuration file profile to bundle with")
+	addBundleFlags(cmd.Flags(), cfg)
+	// Subcommands inherit the template, but not the options.
+	cmd.SetHelpTemplate(cmd.HelpTemplate() + "{{if not .HasParent}}\nEnvironment Variables:\n" + config.EnvHelp(cmd.Flags()) + "{{end}}")
+
+	cmd.AddCommand(newDeanonymizeCmd())
+	cmd.AddCommand(newConfigCmd())
+	cmd.AddCommand(newProfilesCmd())
+
+	return cmd
+}
+
+// addBundleFlags registers the flags of every bundling option in cfg.
+// Their names are also the keys of configuration files.
+func addBundleFlags(flags *pflag.FlagSet, cfg *config.Config) {
+	flags.StringVarP(&cfg.OutputPath, "output", "o", cfg.OutputPath, "Path for the output markdown file. If not specified, prints to stdout.")
+	flags.StringVarP(&cfg.IgnoreFilePath, "ignore-file", "i", "", "Path to a custom .gitignore-style file to use for ignoring files")
+	flags.StringSliceVar(&cfg.Include, "include", cfg.Include, "Only bundle files matching these .gitignore-style patterns")
+	flags.StringSliceVar(&cfg.Exclude, "exclude", cfg.Exclude, "Leave out files and directories matching these .gitignore-style patterns")
+	flags.IntVarP(&cfg.Workers, "workers", "w", cfg.Workers, "Number of concurrent workers for processing files")
+	flags.Int64Var(&cfg.MaxReadBytes, "max-read-bytes", cfg.MaxReadBytes, "Skip files larger than this many bytes (0 for no limit)")
+	flags.DurationVar(&cfg.ReadTimeout, "read-timeout", cfg.ReadTimeout, "Skip files that take longer than this to read (0 for no limit)")
+	flags.BoolVar(&cfg.Strict, "strict", cfg.Strict, "Fail on the first unreadable file or directory instead of skipping it with a warning")
+	flags.StringVar(&cfg.Symlinks, "symlinks", cfg.Symlinks, "What to do with symlinks: skip, follow or record (list them with their target). Links within the directory are always listed")
+	flags.BoolVar(&cfg.SymlinksOutside, "symlinks-outside", cfg.SymlinksOutside, "Follow symlinks to targets outside the directory")
+	flags.BoolVar(&cfg.IncludeHidden, "include-hidden", cfg.IncludeHidden, "Include hidden files and directories (those starting with a dot)")
+	flags.StringToStringVar(&cfg.Languages, "languages", cfg.Languages, "Languages of files by extension (.tmpl=gotemplate) or file name (Jenkinsfile=groovy)")
+	flags.IntVar(&cfg.MaxDepth, "max-depth", cfg.MaxDepth, "Only bundle files this many directory levels deep, the root being 1 (0 for no limit)")
+	flags.IntVar(&cfg.ExcludeLargerThan, "exclude-larger-than", cfg.ExcludeLargerThan, "Leave out files larger than this many kilobytes (0 for no limit)")
+	flags.IntVar(&cfg.ExcludeLongerThan, "exclude-longer-than", cfg.ExcludeLongerThan, "Leave out files longer than this many lines (0 for no limit)")
+	flags.StringVar(&cfg.NewerThan, "newer-than", cfg.NewerThan, "Only bundle files modified after a date (2006-01-02) or within a duration (7d, 12h)")
+	flags.StringSliceVar(&cfg.Langs, "lang", cfg.Langs, "Only bundle files of these detected languages, such as go,sql")
+	flags.StringSliceVar(&cfg.ExcludeLangs, "exclude-lang", cfg.ExcludeLangs, "Leave out files of these detected languages, such as json,yaml")
+	flags.BoolVar(&cfg.StripComments, "strip-comments", cfg.StripComments, "Remove comments from files in languages with known comment syntax")
+	flags.BoolVar(&cfg.KeepDocComments, "keep-doc-comments", cfg.KeepDocComments, "Keep documentation comments when stripping comments")
+	flags.BoolVar(&cfg.NormalizeNewlines, "normalize-newlines", cfg.NormalizeNewlines, "Convert CRLF line endings to LF")
+	flags.BoolVar(&cfg.TrimTrailingWhitespace, "trim-trailing-whitespace", cfg.TrimTrailingWhitespace, "Remove spaces and tabs at the end of lines, except in Markdown")
+	flags.BoolVar(&cfg.CollapseBlankLines, "collapse-blank-lines", cfg.CollapseBlankLines, "Replace runs of blank lines with a single blank line")
+	flags.BoolVar(&cfg.IndentWithTabs, "indent-tabs", cfg.IndentWithTabs, "Convert indentation to tabs, except in indentation-sensitive languages")
+	flags.IntVar(&cfg.TabWidth, "tab-width", cfg.TabWidth, "Number of columns per indentation level when converting indentation to tabs")
+	flags.BoolVar(&cfg.LineNumbers, "line-numbers", cfg.LineNumbers, "Prefix every line of file contents with its line number in the file")
+	flags.StringVar(&cfg.LineNumberFormat, "line-number-format", cfg.LineNumberFormat, "Line number prefix, where {n} is replaced by the number")
+	flags.BoolVar(&cfg.LineNumbersRelative, "line-numbers-relative", cfg.LineNumbersRelative, "Number the lines kept from truncated files from 1 instead of by their position in the file")
+	flags.IntVar(&cfg.MaxFileLines, "max-file-lines", cfg.MaxFileLines, "Truncate files longer than this many lines (0 for no limit)")
+	flags.IntVar(&cfg.MaxFileBytes, "max-file-bytes", cfg.MaxFileBytes, "Truncate files larger than this many bytes (0 for no limit)")
+	flags.StringVar(&cfg.TruncateStrategy, "truncate", cfg.TruncateStrategy, "Lines kept from truncated files: head, head-tail or head-tail-match")
+	flags.StringVar(&cfg.TruncateMatch, "truncate-match", cfg.TruncateMatch, "Regular expression for the extra lines kept by the head-tail-match strategy")
+	flags.StringVar(&cfg.Grep, "grep", cfg.Grep, "Only bundle files whose content matches this regular expression")
+	flags.IntVar(&cfg.GrepContext, "grep-context", cfg.GrepContext, "Only keep the lines --grep matches and this many lines around them, with line numbers (-1 keeps whole files)")
+	flags.StringVar(&cfg.FromTrace, "from-trace", cfg.FromTrace, "Only bundle the files a stack trace or compiler output refers to, read from this file or - for stdin, with the lines marked")
+	flags.IntVar(&cfg.TraceContext, "trace-context", cfg.TraceContext, "Only keep the lines --from-trace refers to and this many lines around them (-1 keeps whole files)")
+	flags.StringVar(&cfg.Coverage, "coverage", cfg.Coverage, "Only bundle files with code a Go coverage profile reports as not covered, with those lines marked")
+	flags.BoolVar(&cfg.CoverageTests, "coverage-tests", cfg.CoverageTests, "With --coverage, also bundle the _test.go files of the packages of those files")
+	flags.StringVar(&cfg.Query, "query", cfg.Query, "Only bundle the files most relevant to this query, ranked by their paths, identifiers and contents")
+	flags.IntVar(&cfg.QueryTop, "query-top", cfg.QueryTop, "Number of files --query selects, unless --query-budget is set (0 for all matching files)")
+	flags.IntVar(&cfg.QueryBudget, "query-budget", cfg.QueryBudget, "Select the best files for --query that fit this many estimated tokens instead of a number of files")
+	flags.StringVar(&cfg.ReportPath, "report", cfg.ReportPath, "Write a JSON report about the bundle to this path")
+	flags.StringVar(&cfg.GeneratedPolicy, "generated", cfg.GeneratedPolicy, "What to do with generated files and lockfiles: include, exclude or list")
+	flags.StringVar(&cfg.VendoredPolicy, "vendored", cfg.VendoredPolicy, "What to do with vendored files: include, exclude or list")
+	flags.StringVar(&cfg.MinifiedPolicy, "minified", cfg.MinifiedPolicy, "What to do with minified files: include, exclude or list")
+	flags.StringVar(&cfg.SecretsMode, "secrets", cfg.SecretsMode, "What to do with credentials found in files: off, warn, redact or abort")
+	flags.StringVar(&cfg.UnicodeCheck, "unicode-check", cfg.UnicodeCheck, "What to do with bidi controls, invisible characters and mixed-script identifiers: off, warn, escape or fail")
+	flags.StringSliceVar(&cfg.MaskValues, "mask-values", cfg.MaskValues, "Patterns of .env, properties, INI, YAML, TOML and JSON files whose values are masked, keeping only keys and structure")
+	flags.StringVar(&cfg.AnonymizeRulesPath, "anonymize", cfg.AnonymizeRulesPath, "Path to a file of terms and re: patterns to replace with placeholders throughout the bundle")
+	flags.StringVar(&cfg.AnonymizeMapPath, "anonymize-map", cfg.AnonymizeMapPath, "Path of the private file mapping anonymization placeholders to the original text")
+	flags.StringVar(&cfg.SecretsAllowlistPath, "secrets-allowlist", cfg.SecretsAllowlistPath, "Path to a file of regular expressions and path: patterns for secrets that are never reported")
+}
+
+// resolveRootDir returns the absolute path of the directory to bundle.
+func resolveRootDir(dir string) (string, error) {
+	rootDir, err := filepath.Abs(dir)
+	if err != nil {
+		return "", fmt.Errorf("invalid directory path: %w", err)
+	}
+	info, err := os.Stat(rootDir)
+	if err != nil {
+		return "", fmt.Errorf("cannot access directory: %w", err)
+	}
+	if !info.IsDir() {
+		return "", fmt.Errorf("path is not a directory: %s", rootDir)
+	}
+	return rootDir, nil
+}
+
+// loadConfig fills cfg from the configuration files, the named profile and
+// the environment, except for the options set on the command line. The directory to bundle
+// is the one in args, or else the root of the profile or the working
+// directory. Unless an option names the output file, the bundle goes to
+// stdout.
+func loadConfig(cmd *cobra.Command, args []string, cfg *config.Config, profileName string) (config.Sources, error) {
+	dir := "."
+	if len(args) == 1 {
+		dir = args[0]
+	}
+	rootDir, err := resolveRootDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	files, err := config.Discover(rootDir)
+	if err != nil {
+		return nil, fmt.Errorf("cannot load configuration: %w", err)
+	}
+
+	if profileName == "" {
+		profileName = os.Getenv(config.EnvVar(config.ProfileFlag))
+	}
+	var profile *config.Profile
+	if profileName != "" {
+		profile, err = files.Profile(profileName)
+		if err != nil {
+			return nil, err
+		}
+		if len(args) == 0 && profile.Root != "" {
+			if rootDir, err = resolveRootDir(profile.Root); err != nil {
+				return nil, err
 			}
-			cfg.RootDir = rootDir
+		}
+	}
+	cfg.RootDir = rootDir
 
-			if !cmd.Flags().Changed("output") {
-				cfg.OutputPath = ""
+	sources, err := files.Apply(cmd.Flags(), profile)
+	if err != nil {
+		return nil, fmt.Errorf("cannot load configuration: %w", err)
+	}
+	if _, ok := sources["output"]; !ok {
+		cfg.OutputPath = ""
+	}
+	return sources, nil
+}
+
+func newConfigCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "config",
+		Short: "Inspects the configuration dirmd runs with.",
+		Long: `Options are read from the command line, then from the closest
+.dirmd.toml, dirmd.toml, .dirmd.yaml or dirmd.yaml in the directory or
+its parents, then from config.toml or config.yaml in $XDG_CONFIG_HOME/dirmd.
+Configuration files use the flag names as keys. Relative paths in them
+are resolved from the working directory, as on the command line.`,
+	}
+	cmd.AddCommand(newConfigShowCmd())
+	return cmd
+}
+
+func newConfigShowCmd() *cobra.Command {
+	cfg := config.NewDefaultConfig()
+	var profile string
+
+	cmd := &cobra.Command{
+		Use:   "show [directory]",
+		Short: "Prints the effective settings for a directory and where each comes from.",
+		Args:  cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			sources, err := loadConfig(cmd, args, cfg, profile)
+			if err != nil {
+				return err
 			}
+			fmt.Fprintf(os.Stdout, "# Settings for %s\n", cfg.RootDir)
+			_, err = fmt.Fprint(os.Stdout, config.Format(cmd.Flags(), sources))
+			return err
+		},
+	}
 
-			b, err := bundler.New(cfg)
+	cmd.Flags().StringVarP(&profile, config.ProfileFlag, "p", "", "Name of the configuration file profile to show the settings of")
+	addBundleFlags(cmd.Flags(), cfg)
+
+	return cmd
+}
+
+func newProfilesCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "profiles",
+		Short: "Inspects the profiles defined in configuration files.",
+		Long: `Profiles are named sets of options in the profiles table of a
+configuration file, selected with --profile. A profile can have a
+description, extend another profile and set the root directory to bundle:
+
+  [profiles.backend-review]
+  description = "Backend code without tests"
+  extends = "base"
+  root = "services"
+  exclude = ["*_test.go"]`,
+	}
+	cmd.AddCommand(newProfilesListCmd())
+	return cmd
+}
+
+func newProfilesListCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "list [directory]",
+		Short: "Lists the profiles available in a directory.",
+		Args:  cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			dir := "."
+			if len(args) == 1 {
+				dir = args[0]
+			}
+			rootDir, err := resolveRootDir(dir)
 			if err != nil {
 				return err
 			}
+			files, err := config.Discover(rootDir)
+			if err != nil {
+				return fmt.Errorf("cannot load configuration: %w", err)
+			}
+
+			profiles := files.Profiles()
+			if len(profiles) == 0 {
+				fmt.Fprintln(os.Stderr, "No profiles defined.")
+				return nil
+			}
+			w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+			for _, name := range slices.Sorted(maps.Keys(profiles)) {
+				profile := profiles[name]
+				description := profile.Description
+				if profile.Extends != "" {
+					description = strings.TrimSpace(description + " (extends " + profile.Extends + ")")
+				}
+				fmt.Fprintf(w, "%s\t%s\n", name, description)
+			}
+			return w.Flush()
+		},
+	}
+}
 
-			return b.Bundle()
+func newDeanonymizeCmd() *cobra.Command {
+	var mapPath, outputPath string
+
+	cmd := &cobra.Command{
+		Use:   "deanonymize [file]",
+		Short: "Restores names replaced by --anonymize in a response or bundle.",
+		Long: `deanonymize reads text produced from an anonymized bundle, such as an
+LLM response or the bundle itself, from a file or standard input and
+replaces every placeholder with the original text recorded in the
+mapping file.`,
+		Args: cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			mapping, err := anonymizer.LoadMapping(mapPath, false)
+			if err != nil {
+				return fmt.Errorf("cannot read mapping: %w", err)
+			}
+
+			var input []byte
+			if len(args) == 1 {
+				input, err = os.ReadFile(args[0])
+			} else {
+				input, err = io.ReadAll(os.Stdin)
+			}
+			if err != nil {
+				return fmt.Errorf("cannot read input: %w", err)
+			}
+
+			restored := mapping.Restore(string(input))
+			if outputPath != "" {
+				return os.WriteFile(outputPath, []byte(restored), 0644)
+			}
+			_, err = fmt.Fprint(os.Stdout, restored)
+			return err
 		},
 	}
 
-	cmd.Flags().StringVarP(&cfg.OutputPath, "output", "o", cfg.OutputPath, "Path for the output markdown file. If not specified, prints to stdout.")
-	cmd.Flags().StringVarP(&cfg.IgnoreFilePath, "ignore-file", "i", "", "Path to a custom .gitignore-style file to use for ignoring files")
-	cmd.Flags().IntVarP(&cfg.Workers, "workers", "w", cfg.Workers, "Number of concurrent workers for processing files")
-	cmd.Flags().BoolVar(&cfg.IncludeHidden, "include-hidden", cfg.IncludeHidden, "Include hidden files and directories (those starting with a dot)")
+	defaults := config.NewDefaultConfig()
+	cmd.Flags().StringVarP(&mapPath, "map", "m", defaults.AnonymizeMapPath, "Path of the mapping file written by --anonymize")
+	cmd.Flags().StringVarP(&outputPath, "output", "o", "", "Path for the restored text. If not specified, prints to stdout.")
 
 	return cmd
 }